@@ -0,0 +1,192 @@
+package acapy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDispatcherClosed is returned by AsyncDispatcher.Dispatch after Shutdown has been called
+var ErrDispatcherClosed = errors.New("dispatcher is shut down")
+
+// AsyncDispatcherOptions configures an AsyncDispatcher, zero values are replaced by defaults
+type AsyncDispatcherOptions struct {
+	// Workers is the number of events that are handled in parallel, 8 by default
+	Workers int
+	// QueueSize is the number of events that can be queued per worker, 64 by default.
+	// Dispatch waits when the queue of a worker is full.
+	QueueSize int
+	// OnError is called when handling an event fails, by default the error is logged
+	OnError func(topic string, body []byte, err error)
+	// QueueUnsupportedTopics queues the events on topics which are not modelled by this library,
+	// for dispatchers which handle them, like WebhookHandlers with a RawEventHandler.
+	// By default Dispatch returns ErrUnsupportedTopic for these events.
+	QueueUnsupportedTopics bool
+}
+
+// AsyncDispatcher handles events in a pool of workers, so slow handlers do not block the delivery of webhooks by ACA-py.
+// Events of the same record, like a credential exchange and the records of its formats, are always handled
+// by the same worker in the order in which they were received, while events of different records are handled in parallel.
+// Events without a record are ordered by their connection.
+//
+// When the dispatcher implements DecodedEventDispatcher, like WebhookHandlers and EventBus,
+// the workers pass it the event which Dispatch decoded instead of decoding the body again.
+type AsyncDispatcher struct {
+	// next is accessed atomically and must be 64-bit aligned
+	next uint64
+
+	dispatcher       EventDispatcher
+	onError          func(topic string, body []byte, err error)
+	queueUnsupported bool
+	queues           []chan asyncEvent
+	workers          sync.WaitGroup
+	closing          chan struct{}
+	closeQueuesOnce  sync.Once
+
+	// mu guards closed and adding to sending, it is never held while waiting for a queue
+	mu      sync.Mutex
+	closed  bool
+	sending sync.WaitGroup
+}
+
+type asyncEvent struct {
+	event Event
+	body  []byte
+}
+
+// NewAsyncDispatcher starts the workers which dispatch events to dispatcher
+func NewAsyncDispatcher(dispatcher EventDispatcher, options AsyncDispatcherOptions) *AsyncDispatcher {
+	if options.Workers <= 0 {
+		options.Workers = 8
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 64
+	}
+	if options.OnError == nil {
+		options.OnError = logWebhookError
+	}
+
+	a := &AsyncDispatcher{
+		dispatcher:       dispatcher,
+		onError:          options.OnError,
+		queueUnsupported: options.QueueUnsupportedTopics,
+		queues:           make([]chan asyncEvent, options.Workers),
+		closing:          make(chan struct{}),
+	}
+	for i := range a.queues {
+		a.queues[i] = make(chan asyncEvent, options.QueueSize)
+		a.workers.Add(1)
+		go a.work(a.queues[i])
+	}
+	return a
+}
+
+func (a *AsyncDispatcher) work(queue chan asyncEvent) {
+	defer a.workers.Done()
+	for queued := range queue {
+		var err error
+		if dispatcher, ok := a.dispatcher.(DecodedEventDispatcher); ok {
+			err = dispatcher.DispatchEvent(queued.event)
+		} else {
+			err = dispatchWallet(a.dispatcher, queued.event.WalletID, queued.event.Topic, queued.body)
+		}
+		if err != nil {
+			a.onError(queued.event.Topic, queued.body, err)
+		}
+	}
+}
+
+// Dispatch queues the event for its worker. The body is validated synchronously,
+// so malformed webhooks are still answered with 400 Bad Request and unsupported topics with 404 Not Found.
+// When the queue of the worker is full, Dispatch waits until there is room or until Shutdown is called.
+func (a *AsyncDispatcher) Dispatch(topic string, body []byte) error {
+	return a.DispatchWallet("", topic, body)
+}
+
+// DispatchWallet queues the event of the sub-wallet walletID like Dispatch, the wallet is passed on in Event.WalletID
+func (a *AsyncDispatcher) DispatchWallet(walletID string, topic string, body []byte) error {
+	if handlers, ok := a.dispatcher.(WebhookHandlers); ok && !handlers.handles(topic) {
+		// Nothing to queue, the handlers answer unsupported topics without decoding the body
+		return handlers.Dispatch(topic, body)
+	}
+	event, err := DecodeEvent(topic, body)
+	if err != nil {
+		return err
+	}
+	if _, ok := event.Payload.(json.RawMessage); ok && !a.queueUnsupported {
+		return fmt.Errorf("%w: %q", ErrUnsupportedTopic, topic)
+	}
+	event.WalletID = walletID
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrDispatcherClosed
+	}
+	a.sending.Add(1)
+	a.mu.Unlock()
+	defer a.sending.Done()
+
+	select {
+	case a.queues[a.worker(event)] <- asyncEvent{event: event, body: body}:
+		return nil
+	case <-a.closing:
+		return ErrDispatcherClosed
+	}
+}
+
+// worker returns the index of the worker which handles all events of the same record or connection
+func (a *AsyncDispatcher) worker(event Event) int {
+	var key = event.RecordID
+	if key == "" {
+		key = event.ConnectionID
+	}
+	if key == "" {
+		// Events without identifiers do not need to be ordered
+		return int(atomic.AddUint64(&a.next, 1) % uint64(len(a.queues)))
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(len(a.queues)))
+}
+
+// Handler returns a webhooks handler which queues every webhook and responds immediately
+func (a *AsyncDispatcher) Handler(onError func(topic string, body []byte, err error)) func(w http.ResponseWriter, r *http.Request) {
+	return webhookHandler(onError, a.Dispatch)
+}
+
+// Shutdown stops accepting events and waits until all queued events are handled or until ctx is done.
+// Calls to Dispatch which are waiting for a full queue return ErrDispatcherClosed.
+func (a *AsyncDispatcher) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.closing)
+	}
+	a.mu.Unlock()
+
+	var drained = make(chan struct{})
+	go func() {
+		// The queues can only be closed when no Dispatch is sending anymore
+		a.sending.Wait()
+		a.closeQueuesOnce.Do(func() {
+			for _, queue := range a.queues {
+				close(queue)
+			}
+		})
+		a.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,226 @@
+package acapy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func connectionEventBody(connectionID string, seq int) []byte {
+	return []byte(fmt.Sprintf(`{"connection_id":%q,"state":"active","seq":%d}`, connectionID, seq))
+}
+
+func TestAsyncDispatcherOrdersEventsOfRecord(t *testing.T) {
+	var mu sync.Mutex
+	var received = map[string][]int{}
+	dispatcher := NewAsyncDispatcher(dispatchFunc(func(topic string, body []byte) error {
+		var event struct {
+			ConnectionID string `json:"connection_id"`
+			Seq          int    `json:"seq"`
+		}
+		json.Unmarshal(body, &event)
+		// Give the other workers a chance to overtake
+		time.Sleep(time.Duration(event.Seq%3) * time.Millisecond)
+		mu.Lock()
+		received[event.ConnectionID] = append(received[event.ConnectionID], event.Seq)
+		mu.Unlock()
+		return nil
+	}), AsyncDispatcherOptions{Workers: 4, QueueSize: 4})
+
+	var connections = []string{"a", "b", "c", "d", "e"}
+	for seq := 0; seq < 20; seq++ {
+		for _, connectionID := range connections {
+			if err := dispatcher.Dispatch(TopicConnections, connectionEventBody(connectionID, seq)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dispatcher.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, connectionID := range connections {
+		seqs := received[connectionID]
+		if len(seqs) != 20 {
+			t.Errorf("expected 20 events of %s, got %d", connectionID, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Errorf("expected the events of %s in order, got %v", connectionID, seqs)
+				break
+			}
+		}
+	}
+}
+
+func TestAsyncDispatcherShutdownDrains(t *testing.T) {
+	var release = make(chan struct{})
+	var mu sync.Mutex
+	var handled int
+	dispatcher := NewAsyncDispatcher(dispatchFunc(func(topic string, body []byte) error {
+		<-release
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		return nil
+	}), AsyncDispatcherOptions{Workers: 2})
+
+	for seq := 0; seq < 10; seq++ {
+		if err := dispatcher.Dispatch(TopicConnections, connectionEventBody(fmt.Sprint(seq), seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Shutdown gives up when ctx is done before the events are handled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := dispatcher.Dispatch(TopicConnections, connectionEventBody("late", 0)); err != ErrDispatcherClosed {
+		t.Errorf("expected ErrDispatcherClosed after Shutdown, got %v", err)
+	}
+
+	var result = make(chan error, 1)
+	go func() {
+		result <- dispatcher.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-result:
+		t.Fatalf("Shutdown returned %v before the queued events were handled", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("expected Shutdown to succeed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not return after the events were handled")
+	}
+	if handled != 10 {
+		t.Errorf("expected all 10 queued events to be handled, got %d", handled)
+	}
+}
+
+func TestAsyncDispatcherShutdownReleasesBlockedSenders(t *testing.T) {
+	var started = make(chan struct{}, 1)
+	var release = make(chan struct{})
+	dispatcher := NewAsyncDispatcher(dispatchFunc(func(topic string, body []byte) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}), AsyncDispatcherOptions{Workers: 1, QueueSize: 1})
+	defer close(release)
+
+	// The first event is taken by the worker, the second fills the queue
+	if err := dispatcher.Dispatch(TopicConnections, connectionEventBody("a", 0)); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := dispatcher.Dispatch(TopicConnections, connectionEventBody("a", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocked = make(chan error, 1)
+	go func() {
+		blocked <- dispatcher.Dispatch(TopicConnections, connectionEventBody("a", 2))
+	}()
+	select {
+	case err := <-blocked:
+		t.Fatalf("Dispatch returned %v instead of waiting for the full queue", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	dispatcher.Shutdown(ctx)
+	select {
+	case err := <-blocked:
+		if err != ErrDispatcherClosed {
+			t.Errorf("expected ErrDispatcherClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not release the blocked Dispatch")
+	}
+}
+
+func TestAsyncDispatcherUnsupportedTopics(t *testing.T) {
+	var tests = []struct {
+		name       string
+		dispatcher func(handled chan<- string) EventDispatcher
+		queue      bool
+		err        error
+	}{
+		{
+			name: "rejected",
+			dispatcher: func(handled chan<- string) EventDispatcher {
+				return dispatchFunc(func(topic string, body []byte) error {
+					handled <- topic
+					return nil
+				})
+			},
+			err: ErrUnsupportedTopic,
+		},
+		{
+			name: "queued",
+			dispatcher: func(handled chan<- string) EventDispatcher {
+				return dispatchFunc(func(topic string, body []byte) error {
+					handled <- topic
+					return nil
+				})
+			},
+			queue: true,
+		},
+		{
+			name: "queued for raw event handler",
+			dispatcher: func(handled chan<- string) EventDispatcher {
+				return WebhookHandlers{
+					RawEventHandler: func(topic string, payload json.RawMessage) {
+						handled <- topic
+					},
+				}
+			},
+			queue: true,
+		},
+		{
+			name: "handlers without raw event handler",
+			dispatcher: func(handled chan<- string) EventDispatcher {
+				return WebhookHandlers{}
+			},
+			queue: true,
+			err:   ErrUnsupportedTopic,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var handled = make(chan string, 1)
+			dispatcher := NewAsyncDispatcher(test.dispatcher(handled), AsyncDispatcherOptions{QueueUnsupportedTopics: test.queue})
+			defer dispatcher.Shutdown(context.Background())
+
+			err := dispatcher.Dispatch("custom_topic", []byte(`{"connection_id":"1"}`))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			if test.err != nil {
+				return
+			}
+			select {
+			case topic := <-handled:
+				if topic != "custom_topic" {
+					t.Errorf("expected custom_topic, got %s", topic)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the event was not handled")
+			}
+		})
+	}
+}
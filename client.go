@@ -2,28 +2,79 @@ package acapy
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Client struct {
 	acapyURL                   string
+	basePath                   string
 	apiKey                     string
+	userAgent                  string
+	defaultHeaders             http.Header
+	tlsConfig                  *tls.Config
+	clientCertificates         []tls.Certificate
+	rootCAs                    *x509.CertPool
+	retryPolicy                *RetryPolicy
+	middleware                 []Middleware
 	tracing                    bool
 	preserveExchangeRecords    bool
 	autoRespondCredentialOffer bool
+	eventBus                   *EventBus
+	pollInterval               time.Duration
+	ctx                        context.Context
 	HTTPClient                 http.Client
 }
 
-func NewClient(acapyURL string) *Client {
-	return &Client{
+// NewClient creates a client for the ACA-py admin API at acapyURL.
+// For example:
+//
+//	client := acapy.NewClient("https://agent.example.com",
+//		acapy.WithAPIKey(apiKey),
+//		acapy.WithBasePath("/admin"),
+//		acapy.WithTimeout(10*time.Second),
+//		acapy.WithClientCertificates(certificate),
+//	)
+func NewClient(acapyURL string, options ...ClientOption) *Client {
+	client := &Client{
 		acapyURL:   strings.TrimRight(acapyURL, "/"),
 		HTTPClient: http.Client{},
 	}
+	for _, option := range options {
+		option(client)
+	}
+	client.configureTLS()
+	return client
+}
+
+// WithContext returns a shallow copy of the client which uses ctx for all admin API calls,
+// so cancellation, deadlines and request-scoped values propagate to ACA-py.
+// For example:
+//
+//	connection, err := client.WithContext(r.Context()).GetConnection(connectionID)
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("acapy: nil context")
+	}
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// Context returns the context used for admin API calls, context.Background() by default
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 func (c *Client) SetAPIKey(apiKey string) *Client {
@@ -51,85 +102,163 @@ func (c *Client) AutoRespondCredentialOffer() *Client {
 	return c
 }
 
-func (c *Client) post(path string, queryParam map[string]string, body interface{}, response interface{}) error {
-	return c.request(http.MethodPost, c.acapyURL+path, queryParam, body, response)
+func (c *Client) post(path string, queryParams map[string]string, body interface{}, response interface{}) error {
+	return c.request(c.Context(), http.MethodPost, path, queryParams, body, response)
+}
+
+// postIdempotent is used for POST requests which can safely be repeated, so they can be retried
+func (c *Client) postIdempotent(path string, queryParams map[string]string, body interface{}, response interface{}) error {
+	return c.send(c.Context(), http.MethodPost, path, queryParams, body, response, true)
 }
 
 func (c *Client) get(path string, queryParams map[string]string, response interface{}) error {
-	return c.request(http.MethodGet, c.acapyURL+path, queryParams, nil, response)
+	return c.request(c.Context(), http.MethodGet, path, queryParams, nil, response)
 }
 
 func (c *Client) patch(path string, queryParams map[string]string, body interface{}, response interface{}) error {
-	return c.request(http.MethodPatch, c.acapyURL+path, queryParams, body, response)
+	return c.request(c.Context(), http.MethodPatch, path, queryParams, body, response)
 }
 
-func (c *Client) put(path string) error {
-	return c.request(http.MethodPut, c.acapyURL+path, nil, nil, nil)
+func (c *Client) put(path string, queryParams map[string]string, body interface{}, response interface{}) error {
+	return c.send(c.Context(), http.MethodPut, path, queryParams, body, response, true)
 }
 
-func (c *Client) delete(url string) error {
-	return c.request(http.MethodDelete, url, nil, nil, nil)
+func (c *Client) delete(path string) error {
+	return c.request(c.Context(), http.MethodDelete, path, nil, nil, nil)
 }
 
-func (c *Client) request(method string, url string, queryParams map[string]string, body interface{}, responseObject interface{}) error {
-	var input io.Reader
-	var err error
+func (c *Client) url(path string) string {
+	return c.acapyURL + c.basePath + path
+}
 
+func (c *Client) headers() http.Header {
+	var header = http.Header{}
+	for key, values := range c.defaultHeaders {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+	if c.userAgent != "" {
+		header.Set("User-Agent", c.userAgent)
+	}
+	if c.apiKey != "" {
+		header.Set("X-API-KEY", c.apiKey)
+	}
+	header.Set("Content-Type", "application/json")
+	return header
+}
+
+func (c *Client) request(ctx context.Context, method string, path string, queryParams map[string]string, body interface{}, responseObject interface{}) error {
+	return c.send(ctx, method, path, queryParams, body, responseObject, false)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, queryParams map[string]string, body interface{}, responseObject interface{}, idempotent bool) error {
+	var jsonInput []byte
 	if body != nil {
-		jsonInput, err := json.Marshal(body)
+		var err error
+		jsonInput, err = json.Marshal(body)
 		if err != nil {
 			return err
 		}
-		input = bytes.NewReader(jsonInput)
 	}
 
-	r, err := http.NewRequest(method, url, input)
+	responseBody, err := c.do(ctx, method, path, queryParams, jsonInput, idempotent)
 	if err != nil {
 		return err
 	}
-	if c.apiKey != "" {
-		r.Header.Add("X-API-KEY", c.apiKey)
+
+	if responseObject != nil {
+		return json.Unmarshal(responseBody, responseObject)
 	}
-	r.Header.Add("Content-Type", "application/json")
+	return nil
+}
 
-	q := r.URL.Query()
-	for k, v := range queryParams {
-		if k != "" && v != "" {
-			q.Add(k, v)
+// do performs the request through the middleware, retrying it according to the retry policy, and returns the response body
+func (c *Client) do(ctx context.Context, method string, path string, queryParams map[string]string, body []byte, idempotent bool) ([]byte, error) {
+	var policy = c.retryPolicy
+	var retryable = policy != nil && (method == http.MethodGet || (idempotent && policy.RetryIdempotentRequests))
+	var handler = c.handler()
+
+	for attempt := 1; ; attempt++ {
+		response, err := handler(ctx, &AdminRequest{
+			Method:      method,
+			Path:        path,
+			QueryParams: queryParams,
+			Body:        body,
+			Header:      c.headers(),
+		})
+		if err == nil && response == nil {
+			return nil, fmt.Errorf("acapy: middleware returned no response for %s %s", method, path)
+		}
+		if err == nil && (response.StatusCode < 200 || response.StatusCode >= 300) {
+			err = newAPIError(method, path, response)
+		}
+		if err == nil {
+			return response.Body, nil
 		}
-	}
-	r.URL.RawQuery = q.Encode()
 
-	response, err := c.HTTPClient.Do(r)
-	if err != nil || response.StatusCode >= 300 {
+		var statusCode int
 		if response != nil {
-			log.Printf("Request failed: %s", response.Status)
-			if body, err := ioutil.ReadAll(response.Body); err != nil {
-				log.Printf("Response body: %s", body)
-			}
+			statusCode = response.StatusCode
+		}
+		if !retryable || attempt >= policy.MaxAttempts || !policy.shouldRetry(ctx, statusCode, err) {
+			return nil, err
 		}
-		return err
-	}
 
-	if responseObject != nil {
-		err = json.NewDecoder(response.Body).Decode(responseObject)
-		if err != nil {
-			return err
+		delay := policy.backoff(attempt, response)
+		if policy.OnRetry != nil {
+			policy.OnRetry(RetryEvent{
+				Method:  method,
+				Path:    path,
+				Attempt: attempt,
+				Delay:   delay,
+				Err:     err,
+			})
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
 		}
 	}
-	return nil
 }
 
-func (c *Client) getFile(url string) ([]byte, error) {
-	r, err := http.NewRequest(http.MethodGet, url, nil)
+// roundTrip is the innermost AdminHandler which sends the request to ACA-py
+func (c *Client) roundTrip(ctx context.Context, request *AdminRequest) (*AdminResponse, error) {
+	var input io.Reader
+	if request.Body != nil {
+		input = bytes.NewReader(request.Body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, request.Method, c.url(request.Path), input)
 	if err != nil {
 		return nil, err
 	}
+	r.Header = request.Header
+
+	q := r.URL.Query()
+	for k, v := range request.QueryParams {
+		if k != "" && v != "" {
+			q.Add(k, v)
+		}
+	}
+	r.URL.RawQuery = q.Encode()
 
 	response, err := c.HTTPClient.Do(r)
 	if err != nil {
 		return nil, err
 	}
 	defer response.Body.Close()
-	return ioutil.ReadAll(response.Body)
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       responseBody,
+	}, nil
+}
+
+func (c *Client) getFile(ctx context.Context, path string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, path, nil, nil, false)
 }
@@ -1,29 +1,33 @@
 package acapy
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 )
 
 type Connection struct {
-	Accept              string `json:"accept"` // auto / manual
-	Alias               string `json:"alias"`
-	ConnectionID        string `json:"connection_id"`
-	CreatedAt           string `json:"created_at"`
-	ErrorMsg            string `json:"error_msg"`
-	InboundConnectionID string `json:"inbound_connection_id"`
-	InvitationKey       string `json:"invitation_key"`
-	InvitationMode      string `json:"invitation_mode"` // once / multi
-	InvitationMessageID string `json:"invitation_msg_id"`
-	MyDID               string `json:"my_did"`
-	RequestID           string `json:"request_id"`
-	RFC23State          string `json:"rfc23_state"`
-	RoutingState        string `json:"routing_state"`
-	State               string `json:"state"`
-	TheirDID            string `json:"their_did"`
-	TheirLabel          string `json:"their_label"`
-	TheirRole           string `json:"their_role"`
-	UpdatedAt           string `json:"updated_at"`
+	Accept              string          `json:"accept"` // auto / manual
+	Alias               string          `json:"alias"`
+	ConnectionID        string          `json:"connection_id"`
+	CreatedAt           Timestamp       `json:"created_at"`
+	ErrorMsg            string          `json:"error_msg"`
+	InboundConnectionID string          `json:"inbound_connection_id"`
+	InvitationKey       string          `json:"invitation_key"`
+	InvitationMode      string          `json:"invitation_mode"` // once / multi
+	InvitationMessageID string          `json:"invitation_msg_id"`
+	MyDID               string          `json:"my_did"`
+	RequestID           string          `json:"request_id"`
+	RFC23State          RFC23State      `json:"rfc23_state"`
+	RoutingState        string          `json:"routing_state"`
+	State               ConnectionState `json:"state"`
+	TheirDID            string          `json:"their_did"`
+	TheirLabel          string          `json:"their_label"`
+	// TheirPublicDID is the public DID of the invitation, when the invitation was created with a public DID
+	TheirPublicDID string         `json:"their_public_did"`
+	TheirRole      ConnectionRole `json:"their_role"`
+	UpdatedAt      Timestamp      `json:"updated_at"`
 }
 
 type CreateInvitationResponse struct {
@@ -41,27 +45,74 @@ type CreateInvitationResponse struct {
 	} `json:"invitation,omitempty"`
 }
 
+// CreateInvitationRequest covers all parameters of /connections/create-invitation
+type CreateInvitationRequest struct {
+	// Alias, AutoAccept, MultiUse and Public are sent as query parameters
+	Alias      string `json:"-"`
+	AutoAccept bool   `json:"-"`
+	MultiUse   bool   `json:"-"`
+	Public     bool   `json:"-"`
+
+	// MyLabel overrides the label of this agent in the invitation
+	MyLabel string `json:"my_label,omitempty"`
+	// ServiceEndpoint overrides the endpoint of this agent in the invitation
+	ServiceEndpoint string   `json:"service_endpoint,omitempty"`
+	RecipientKeys   []string `json:"recipient_keys,omitempty"`
+	RoutingKeys     []string `json:"routing_keys,omitempty"`
+	MediationID     string   `json:"mediation_id,omitempty"`
+	// Metadata is stored on the connection that is created
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
 func (c *Client) CreateInvitation(alias string, autoAccept bool, multiUse bool, public bool) (CreateInvitationResponse, error) {
+	return c.CreateInvitationWithRequest(CreateInvitationRequest{
+		Alias:      alias,
+		AutoAccept: autoAccept,
+		MultiUse:   multiUse,
+		Public:     public,
+	})
+}
+
+func (c *Client) CreateInvitationWithRequest(request CreateInvitationRequest) (CreateInvitationResponse, error) {
 	var createInvitationResponse CreateInvitationResponse
 	queryParams := map[string]string{
-		"alias":       alias,
-		"auto_accept": strconv.FormatBool(autoAccept),
-		"multi_use":   strconv.FormatBool(multiUse),
-		"public":      strconv.FormatBool(public),
+		"alias":       request.Alias,
+		"auto_accept": strconv.FormatBool(request.AutoAccept),
+		"multi_use":   strconv.FormatBool(request.MultiUse),
+		"public":      strconv.FormatBool(request.Public),
 	}
-	//var createInvitationRequest CreateInvitationRequest
-	err := c.post("/connections/create-invitation", queryParams, nil, &createInvitationResponse)
+	err := c.post("/connections/create-invitation", queryParams, request, &createInvitationResponse)
 	if err != nil {
 		return CreateInvitationResponse{}, err
 	}
 	return createInvitationResponse, nil
 }
 
+// ReceiveInvitationOptions are the query parameters of /connections/receive-invitation
+// and /out-of-band/receive-invitation
+type ReceiveInvitationOptions struct {
+	// Alias of the connection, independent of the label in the invitation
+	Alias       string
+	AutoAccept  bool
+	MediationID string
+	// UseExistingConnection only applies to out-of-band invitations, when nil the default of ACA-py is used,
+	// which reuses an existing connection with the inviter
+	UseExistingConnection *bool
+}
+
 func (c *Client) ReceiveInvitation(invitation Invitation, autoAccept bool) (Connection, error) {
+	return c.ReceiveInvitationWithOptions(invitation, ReceiveInvitationOptions{
+		Alias:      invitation.Label,
+		AutoAccept: autoAccept,
+	})
+}
+
+func (c *Client) ReceiveInvitationWithOptions(invitation Invitation, options ReceiveInvitationOptions) (Connection, error) {
 	var connection Connection
 	err := c.post("/connections/receive-invitation", map[string]string{
-		"alias":       invitation.Label,
-		"auto_accept": strconv.FormatBool(autoAccept),
+		"alias":        options.Alias,
+		"auto_accept":  strconv.FormatBool(options.AutoAccept),
+		"mediation_id": options.MediationID,
 	}, invitation, &connection)
 	if err != nil {
 		return Connection{}, err
@@ -70,6 +121,7 @@ func (c *Client) ReceiveInvitation(invitation Invitation, autoAccept bool) (Conn
 }
 
 type Invitation struct {
+	Type            string   `json:"@type,omitempty"`
 	ID              string   `json:"@id,omitempty"`
 	DID             string   `json:"did,omitempty"`
 	ImageURL        string   `json:"imageUrl,omitempty"`
@@ -107,7 +159,7 @@ type QueryConnectionsParams struct {
 	Alias string `json:"alias,omitempty"`
 
 	// Initiator is Connection invitation initiator
-	Initiator string `json:"initiator,omitempty"`
+	Initiator Initiator `json:"initiator,omitempty"`
 
 	// Invitation key
 	InvitationKey string `json:"invitation_key,omitempty"`
@@ -116,13 +168,13 @@ type QueryConnectionsParams struct {
 	MyDID string `json:"my_did,omitempty"`
 
 	// State of the connection invitation
-	State string `json:"state"`
+	State ConnectionState `json:"state"`
 
 	// TheirDID is other party's DID
 	TheirDID string `json:"their_did,omitempty"`
 
 	// TheirRole is other party's role
-	TheirRole string `json:"their_role,omitempty"`
+	TheirRole ConnectionRole `json:"their_role,omitempty"`
 }
 
 func (c *Client) QueryConnections(params *QueryConnectionsParams) ([]Connection, error) {
@@ -134,12 +186,12 @@ func (c *Client) QueryConnections(params *QueryConnectionsParams) ([]Connection,
 	if params != nil {
 		queryParams = map[string]string{
 			"alias":            params.Alias,
-			"initiator":        params.Initiator,
+			"initiator":        string(params.Initiator),
 			"invitation_key":   params.InvitationKey,
 			"my_did":           params.MyDID,
-			"connection_state": params.State,
+			"connection_state": string(params.State),
 			"their_did":        params.TheirDID,
-			"their_role":       params.TheirRole,
+			"their_role":       string(params.TheirRole),
 		}
 	}
 
@@ -169,10 +221,25 @@ type Thread struct {
 
 // Trust Ping
 func (c *Client) SendPing(connectionID string) (Thread, error) {
+	return c.SendPingWithOptions(connectionID, PingOptions{})
+}
+
+// PingOptions configures a trust ping, zero values are replaced by defaults.
+// ACA-py always requests a response to the pings it sends.
+type PingOptions struct {
+	// Comment is "ping" by default
+	Comment string
+}
+
+// SendPingWithOptions sends a trust ping and returns its thread, the ping event of the response has the same thread ID
+func (c *Client) SendPingWithOptions(connectionID string, options PingOptions) (Thread, error) {
+	if options.Comment == "" {
+		options.Comment = "ping"
+	}
 	ping := struct {
 		Comment string `json:"comment"`
 	}{
-		Comment: "ping",
+		Comment: options.Comment,
 	}
 	var thread Thread
 	err := c.post(fmt.Sprintf("/connections/%s/send-ping", connectionID), nil, ping, &thread)
@@ -182,4 +249,127 @@ func (c *Client) SendPing(connectionID string) (Thread, error) {
 	return thread, nil
 }
 
-// TODO CreateStaticConnection EstablishInboundConnection
+// CreateStaticConnectionRequest creates a connection with an agent of which the DID and endpoint are known,
+// without exchanging an invitation. Seeds are optional, DIDs and keys are generated when they are left empty.
+type CreateStaticConnectionRequest struct {
+	MySeed        string `json:"my_seed,omitempty"`
+	MyDID         string `json:"my_did,omitempty"`
+	TheirSeed     string `json:"their_seed,omitempty"`
+	TheirDID      string `json:"their_did,omitempty"`
+	TheirVerkey   string `json:"their_verkey,omitempty"`
+	TheirEndpoint string `json:"their_endpoint,omitempty"`
+	TheirLabel    string `json:"their_label,omitempty"`
+	Alias         string `json:"alias,omitempty"`
+}
+
+type StaticConnection struct {
+	MyDID       string     `json:"my_did"`
+	MyVerkey    string     `json:"my_verkey"`
+	MyEndpoint  string     `json:"my_endpoint"`
+	TheirDID    string     `json:"their_did"`
+	TheirVerkey string     `json:"their_verkey"`
+	Connection  Connection `json:"record"`
+}
+
+func (c *Client) CreateStaticConnection(request CreateStaticConnectionRequest) (StaticConnection, error) {
+	var staticConnection StaticConnection
+	err := c.post("/connections/create-static", nil, request, &staticConnection)
+	if err != nil {
+		return StaticConnection{}, err
+	}
+	return staticConnection, nil
+}
+
+// EstablishInboundConnection routes the inbound messages of the connection through the connection with
+// inboundConnectionID, for example a connection with a mediator. ACA-py does not return the connection,
+// so it is fetched after the connection is established.
+func (c *Client) EstablishInboundConnection(connectionID string, inboundConnectionID string) (Connection, error) {
+	err := c.post(fmt.Sprintf("/connections/%s/establish-inbound/%s", connectionID, inboundConnectionID), nil, nil, nil)
+	if err != nil {
+		return Connection{}, err
+	}
+	return c.GetConnection(connectionID)
+}
+
+// ErrMetadataKeyNotFound is returned when a connection has no metadata with the key
+var ErrMetadataKeyNotFound = errors.New("acapy: connection metadata key not found")
+
+// ConnectionMetadata is the metadata stored on a connection, mapping keys to their JSON values
+type ConnectionMetadata map[string]json.RawMessage
+
+// Get unmarshals the value of key into value, or returns ErrMetadataKeyNotFound when key is not set
+func (m ConnectionMetadata) Get(key string, value interface{}) error {
+	data, ok := m[key]
+	if !ok || string(data) == "null" {
+		return ErrMetadataKeyNotFound
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *Client) GetConnectionMetadata(connectionID string) (ConnectionMetadata, error) {
+	var result = struct {
+		Results ConnectionMetadata `json:"results"`
+	}{}
+	err := c.get(fmt.Sprintf("/connections/%s/metadata", connectionID), nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Results == nil {
+		return ConnectionMetadata{}, nil
+	}
+	return result.Results, nil
+}
+
+// GetConnectionMetadataKey unmarshals the metadata value of key into value, which can be any type
+// that the value was stored as, for example:
+//
+//	var customer Customer
+//	err := client.GetConnectionMetadataKey(connectionID, "customer", &customer)
+//
+// ErrMetadataKeyNotFound is returned when the connection has no metadata with the key.
+func (c *Client) GetConnectionMetadataKey(connectionID string, key string, value interface{}) error {
+	var result = struct {
+		Results json.RawMessage `json:"results"`
+	}{}
+	err := c.get(fmt.Sprintf("/connections/%s/metadata", connectionID), map[string]string{"key": key}, &result)
+	if err != nil {
+		return err
+	}
+	if len(result.Results) == 0 || string(result.Results) == "null" {
+		return ErrMetadataKeyNotFound
+	}
+	return json.Unmarshal(result.Results, value)
+}
+
+// SetConnectionMetadata sets the keys in metadata on the connection, other keys are left as they are.
+// It returns all metadata of the connection.
+func (c *Client) SetConnectionMetadata(connectionID string, metadata map[string]interface{}) (ConnectionMetadata, error) {
+	var body = struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}{
+		Metadata: metadata,
+	}
+	var result = struct {
+		Results ConnectionMetadata `json:"results"`
+	}{}
+	err := c.post(fmt.Sprintf("/connections/%s/metadata", connectionID), nil, body, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Results == nil {
+		return ConnectionMetadata{}, nil
+	}
+	return result.Results, nil
+}
+
+// SetConnectionMetadataKey stores value as JSON under key on the connection
+func (c *Client) SetConnectionMetadataKey(connectionID string, key string, value interface{}) error {
+	_, err := c.SetConnectionMetadata(connectionID, map[string]interface{}{key: value})
+	return err
+}
+
+// DeleteConnectionMetadataKey removes key from the metadata of the connection. ACA-py has no endpoint
+// to delete metadata, so the key is set to null, which GetConnectionMetadataKey reports as not found.
+func (c *Client) DeleteConnectionMetadataKey(connectionID string, key string) error {
+	return c.SetConnectionMetadataKey(connectionID, key, nil)
+}
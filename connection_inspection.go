@@ -0,0 +1,113 @@
+package acapy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ConnectionEndpoints struct {
+	MyEndpoint    string `json:"my_endpoint"`
+	TheirEndpoint string `json:"their_endpoint"`
+}
+
+func (c *Client) GetConnectionEndpoints(connectionID string) (ConnectionEndpoints, error) {
+	var endpoints ConnectionEndpoints
+	err := c.get(fmt.Sprintf("/connections/%s/endpoints", connectionID), nil, &endpoints)
+	if err != nil {
+		return ConnectionEndpoints{}, err
+	}
+	return endpoints, nil
+}
+
+// ConnectionInspection tells where the messages of a connection are sent
+type ConnectionInspection struct {
+	Connection    Connection
+	MyEndpoint    string
+	TheirEndpoint string
+	// InvitationKey is the recipient key of the invitation when the other agent created it,
+	// the other agent can use different keys for the connection
+	InvitationKey string
+	// TheirVerkeys are the recipient keys of the resolved DID document, empty when it was not resolved
+	TheirVerkeys     []string
+	TheirRoutingKeys []string
+	// TheirDIDDocument is the resolved DID document of the other agent, nil when it was not resolved
+	TheirDIDDocument *DIDDocument
+	// ResolveError is the error resolving the DID document of the other agent
+	ResolveError error
+}
+
+// InspectConnection returns the endpoints of a connection and the keys of the other agent stored with the connection.
+// When their_did is a qualified DID, like the did:peer DIDs of DID exchange, or the invitation was created
+// with a public DID, the DID document is resolved for the verkeys and routing keys of its DIDComm services.
+// The unqualified pairwise DIDs of the connections protocol are not written to a ledger and are not resolved.
+func (c *Client) InspectConnection(connectionID string) (ConnectionInspection, error) {
+	connection, err := c.GetConnection(connectionID)
+	if err != nil {
+		return ConnectionInspection{}, err
+	}
+	endpoints, err := c.GetConnectionEndpoints(connectionID)
+	if err != nil {
+		return ConnectionInspection{}, err
+	}
+
+	var inspection = ConnectionInspection{
+		Connection:    connection,
+		MyEndpoint:    endpoints.MyEndpoint,
+		TheirEndpoint: endpoints.TheirEndpoint,
+	}
+	if connection.TheirRole == ConnectionRoleInviter || connection.TheirRole == ConnectionRoleResponder {
+		inspection.InvitationKey = connection.InvitationKey
+	}
+
+	var did string
+	switch {
+	case strings.HasPrefix(connection.TheirDID, "did:"):
+		did = connection.TheirDID
+	case connection.TheirPublicDID != "":
+		did = qualifyDID(connection.TheirPublicDID)
+	default:
+		return inspection, nil
+	}
+	resolution, err := c.ResolveDID(did)
+	if err != nil {
+		inspection.ResolveError = err
+		return inspection, nil
+	}
+	inspection.TheirDIDDocument = &resolution.DIDDocument
+	for _, verkey := range resolution.DIDDocument.Verkeys() {
+		inspection.TheirVerkeys = appendUnique(inspection.TheirVerkeys, verkey)
+	}
+	for _, routingKey := range resolution.DIDDocument.RoutingKeys() {
+		inspection.TheirRoutingKeys = appendUnique(inspection.TheirRoutingKeys, routingKey)
+	}
+	return inspection, nil
+}
+
+// ConnectivityReport is the inspection of a connection together with the round trip of a trust ping
+type ConnectivityReport struct {
+	ConnectionInspection
+	// ThreadID is the thread of the trust ping
+	ThreadID  string
+	RoundTrip time.Duration
+}
+
+// CheckConnectivity inspects a connection and measures the round trip of a trust ping, see Ping.
+// When ctx is done before the response is received, the report is returned with ctx.Err().
+func (c *Client) CheckConnectivity(ctx context.Context, connectionID string) (ConnectivityReport, error) {
+	if c.eventBus == nil {
+		return ConnectivityReport{}, ErrNoEventBus
+	}
+
+	inspection, err := c.WithContext(ctx).InspectConnection(connectionID)
+	if err != nil {
+		return ConnectivityReport{}, err
+	}
+	var report = ConnectivityReport{ConnectionInspection: inspection}
+
+	result, err := c.Ping(ctx, connectionID, PingOptions{})
+	report.ThreadID = result.ThreadID
+	report.RoundTrip = result.RoundTrip
+	return report, err
+}
@@ -226,10 +226,10 @@ func (c *Client) StoreCredentialByID(credentialExchangeID string, credentialID s
 }
 
 type QueryCredentialExchangeParams struct {
-	ConnectionID string `json:"connection_id"`
-	Role         string `json:"role"`
-	State        string `json:"state"`
-	ThreadID     string `json:"thread_id"`
+	ConnectionID string                  `json:"connection_id"`
+	Role         CredentialExchangeRole  `json:"role"`
+	State        CredentialExchangeState `json:"state"`
+	ThreadID     string                  `json:"thread_id"`
 }
 
 func (c *Client) QueryCredentialExchange(params QueryCredentialExchangeParams) ([]CredentialExchangeRecord, error) {
@@ -238,8 +238,8 @@ func (c *Client) QueryCredentialExchange(params QueryCredentialExchangeParams) (
 	}{}
 	var queryParams = map[string]string{
 		"connection_id": params.ConnectionID,
-		"role":          params.Role,
-		"state":         params.State,
+		"role":          string(params.Role),
+		"state":         string(params.State),
 		"thread_id":     params.ThreadID,
 	}
 	err := c.get("/issue-credential/records", queryParams, &result)
@@ -10,7 +10,7 @@ type CredentialExchangeRecord struct {
 	SchemaID                  string                    `json:"schema_id"`
 	RevocationID              string                    `json:"revocation_id"`
 	RevocationRegistryID      string                    `json:"revoc_reg_id"`
-	State                     string                    `json:"state"`
+	State                     CredentialExchangeState   `json:"state"`
 	CredentialOffer           CredentialOffer           `json:"credential_offer"`
 	CredentialOfferMap        CredentialOfferMap        `json:"credential_offer_dict"`
 	CredentialProposalMap     CredentialProposal        `json:"credential_proposal_dict"`
@@ -18,10 +18,10 @@ type CredentialExchangeRecord struct {
 	CredentialRequestMetadata CredentialRequestMetadata `json:"credential_request_metadata"`
 	Credential                Credential                `json:"credential"`
 	RawCredential             RawCredential             `json:"raw_credential"`
-	Role                      string                    `json:"role"`
-	Initiator                 string                    `json:"initiator"`
-	CreatedAt                 string                    `json:"created_at"`
-	UpdatedAt                 string                    `json:"updated_at"`
+	Role                      CredentialExchangeRole    `json:"role"`
+	Initiator                 Initiator                 `json:"initiator"`
+	CreatedAt                 Timestamp                 `json:"created_at"`
+	UpdatedAt                 Timestamp                 `json:"updated_at"`
 	ErrorMessage              string                    `json:"error_msg"`
 	Trace                     bool                      `json:"trace"`
 	AutoOffer                 bool                      `json:"auto_offer"`
@@ -7,17 +7,17 @@ type CredentialExchangeRecordV2 struct {
 	ConnectionID               string                      `json:"conn_id"`
 	ThreadID                   string                      `json:"thread_id"`
 	ParentThreadID             string                      `json:"parent_thread_id"`
-	State                      string                      `json:"state"`
+	State                      CredentialExchangeStateV2   `json:"state"`
 	CredentialPreview          CredentialPreviewV2         `json:"cred_preview"`
 	CredentialOffer            CredentialOfferV2           `json:"cred_offer"`
 	CredentialProposal         CredentialProposalV2        `json:"cred_proposal"`
 	CredentialRequest          CredentialRequestV2         `json:"cred_request"`
 	CredentialRequestMetadata  CredentialRequestMetadataV2 `json:"cred_request_metadata"`
 	CredentialIssue            CredentialIssue             `json:"cred_issue"`
-	Role                       string                      `json:"role"`
-	Initiator                  string                      `json:"initiator"`
-	CreatedAt                  string                      `json:"created_at"`
-	UpdatedAt                  string                      `json:"updated_at"`
+	Role                       CredentialExchangeRole      `json:"role"`
+	Initiator                  Initiator                   `json:"initiator"`
+	CreatedAt                  Timestamp                   `json:"created_at"`
+	UpdatedAt                  Timestamp                   `json:"updated_at"`
 	ErrorMessage               string                      `json:"error_msg"`
 	Trace                      bool                        `json:"trace"`
 	AutoOffer                  bool                        `json:"auto_offer"`
@@ -109,20 +109,20 @@ type CredentialIssue struct {
 }
 
 type CredentialExchangeDIF struct {
-	CredentialExchangeDIFID string `json:"cred_ex_dif_id"`
-	CreatedAt               string `json:"created_at"`
-	CredentialExchangeID    string `json:"cred_ex_id"`
-	Item                    string `json:"item"`
-	State                   string `json:"state"`
-	UpdatedAt               string `json:"updated_at"`
+	CredentialExchangeDIFID string    `json:"cred_ex_dif_id"`
+	CreatedAt               Timestamp `json:"created_at"`
+	CredentialExchangeID    string    `json:"cred_ex_id"`
+	Item                    string    `json:"item"`
+	State                   string    `json:"state"`
+	UpdatedAt               Timestamp `json:"updated_at"`
 }
 
 type CredentialExchangeIndy struct {
-	CredentialExchangeIndyID  string `json:"cred_ex_indy_id"`
-	CreatedAt                 string `json:"created_at"`
-	UpdatedAt                 string `json:"updated_at"`
-	CredentialExchangeID      string `json:"cred_ex_id"`
-	RevocationRegistryID      string `json:"rev_reg_id"`
+	CredentialExchangeIndyID  string    `json:"cred_ex_indy_id"`
+	CreatedAt                 Timestamp `json:"created_at"`
+	UpdatedAt                 Timestamp `json:"updated_at"`
+	CredentialExchangeID      string    `json:"cred_ex_id"`
+	RevocationRegistryID      string    `json:"rev_reg_id"`
 	CredentialRequestMetadata struct {
 		MasterSecretBlindingData struct {
 			VPrime  string      `json:"v_prime"`
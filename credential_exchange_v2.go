@@ -243,10 +243,10 @@ func (c *Client) StoreCredentialByIDV2(credentialExchangeID string, credentialID
 }
 
 type QueryCredentialExchangeParamsV2 struct {
-	ConnectionID string `json:"connection_id"`
-	Role         string `json:"role"`
-	State        string `json:"state"`
-	ThreadID     string `json:"thread_id"`
+	ConnectionID string                    `json:"connection_id"`
+	Role         CredentialExchangeRole    `json:"role"`
+	State        CredentialExchangeStateV2 `json:"state"`
+	ThreadID     string                    `json:"thread_id"`
 }
 
 func (c *Client) QueryCredentialExchangeV2(params QueryCredentialExchangeParamsV2) ([]CredentialExchangeRecordResult, error) {
@@ -255,8 +255,8 @@ func (c *Client) QueryCredentialExchangeV2(params QueryCredentialExchangeParamsV
 	}{}
 	var queryParams = map[string]string{
 		"connection_id": params.ConnectionID,
-		"role":          params.Role,
-		"state":         params.State,
+		"role":          string(params.Role),
+		"state":         string(params.State),
 		"thread_id":     params.ThreadID,
 	}
 	err := c.get("/issue-credential-2.0/records", queryParams, &result)
@@ -0,0 +1,350 @@
+package acapy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiscoverFeaturesQuery is the query message of discover-features 1.0 (RFC 0031)
+type DiscoverFeaturesQuery struct {
+	Type string `json:"@type,omitempty"`
+	ID   string `json:"@id,omitempty"`
+	// Query is a protocol URI, which may end with a * wildcard
+	Query   string `json:"query"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// DiscoverFeaturesDisclose is the disclose message of discover-features 1.0 (RFC 0031)
+type DiscoverFeaturesDisclose struct {
+	Type      string               `json:"@type,omitempty"`
+	ID        string               `json:"@id,omitempty"`
+	Protocols []ProtocolDescriptor `json:"protocols"`
+}
+
+type ProtocolDescriptor struct {
+	// PID is the protocol URI, for example https://didcomm.org/issue-credential/2.0
+	PID   string   `json:"pid"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// FeatureType is the type of feature queried and disclosed by discover-features 2.0 (RFC 0557)
+type FeatureType string
+
+const (
+	FeatureTypeProtocol FeatureType = "protocol"
+	FeatureTypeGoalCode FeatureType = "goal-code"
+)
+
+type FeatureQuery struct {
+	FeatureType FeatureType `json:"feature-type"`
+	// Match is a protocol URI or goal code, which may end with a * wildcard
+	Match string `json:"match"`
+}
+
+// DiscoverFeaturesQueries is the queries message of discover-features 2.0 (RFC 0557)
+type DiscoverFeaturesQueries struct {
+	Type    string         `json:"@type,omitempty"`
+	ID      string         `json:"@id,omitempty"`
+	Queries []FeatureQuery `json:"queries"`
+}
+
+type FeatureDisclosure struct {
+	FeatureType FeatureType `json:"feature-type"`
+	// ID is the protocol URI or goal code
+	ID    string   `json:"id"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// DiscoverFeaturesDisclosures is the disclosures message of discover-features 2.0 (RFC 0557)
+type DiscoverFeaturesDisclosures struct {
+	Type        string              `json:"@type,omitempty"`
+	ID          string              `json:"@id,omitempty"`
+	Disclosures []FeatureDisclosure `json:"disclosures"`
+}
+
+// QueryFeatures sends a discover-features 1.0 query to the agent of the connection, for example
+// https://didcomm.org/issue-credential/*. The disclose message is received asynchronously on the
+// discover_feature topic, use DiscoverFeatures to wait for it.
+func (c *Client) QueryFeatures(connectionID string, query string, comment string) (DiscoveryExchangeRecord, error) {
+	var result = struct {
+		Results DiscoveryExchangeRecord `json:"results"`
+	}{}
+	var queryParams = map[string]string{
+		"connection_id": connectionID,
+		"query":         query,
+		"comment":       comment,
+	}
+	err := c.get("/discover-features/query", queryParams, &result)
+	if err != nil {
+		return DiscoveryExchangeRecord{}, err
+	}
+	return result.Results, nil
+}
+
+func (c *Client) QueryDiscoveryExchangeRecords(connectionID string) ([]DiscoveryExchangeRecord, error) {
+	var result = struct {
+		Results []DiscoveryExchangeRecord `json:"results"`
+	}{}
+	var queryParams = map[string]string{
+		"connection_id": connectionID,
+	}
+	err := c.get("/discover-features/records", queryParams, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// QueryFeaturesV2 sends a discover-features 2.0 query for protocols, goal codes or both to the agent
+// of the connection. The disclosures message is received asynchronously on the discover_feature_v2_0 topic,
+// use DiscoverFeaturesV2 to wait for it.
+func (c *Client) QueryFeaturesV2(connectionID string, queryProtocol string, queryGoalCode string) (DiscoveryExchangeRecordV2, error) {
+	var result = struct {
+		Results DiscoveryExchangeRecordV2 `json:"results"`
+	}{}
+	var queryParams = map[string]string{
+		"connection_id":   connectionID,
+		"query_protocol":  queryProtocol,
+		"query_goal_code": queryGoalCode,
+	}
+	err := c.get("/discover-features-2.0/queries", queryParams, &result)
+	if err != nil {
+		return DiscoveryExchangeRecordV2{}, err
+	}
+	return result.Results, nil
+}
+
+func (c *Client) QueryDiscoveryExchangeRecordsV2(connectionID string) ([]DiscoveryExchangeRecordV2, error) {
+	var result = struct {
+		Results []DiscoveryExchangeRecordV2 `json:"results"`
+	}{}
+	var queryParams = map[string]string{
+		"connection_id": connectionID,
+	}
+	err := c.get("/discover-features-2.0/records", queryParams, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// discoveryDisclosed is the pseudo state of a discovery exchange record which has received the disclosure,
+// so the WaitFor machinery can wait for it
+const discoveryDisclosed = "disclosed"
+
+// DiscoverFeatures queries the features of the agent of the connection with discover-features 1.0
+// and blocks until the disclose message is received. An empty query queries all protocols.
+// Like the WaitFor helpers it waits for events when the client has an event bus, and polls otherwise.
+func (c *Client) DiscoverFeatures(ctx context.Context, connectionID string, query string) (Capabilities, error) {
+	if query == "" {
+		query = "*"
+	}
+	exchange, err := c.WithContext(ctx).QueryFeatures(connectionID, query, "")
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	var w = discoveryWaiter(TopicDiscoverFeature, exchange.DiscoveryExchangeID,
+		func(c *Client) (interface{}, error) {
+			records, err := c.QueryDiscoveryExchangeRecords(connectionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range records {
+				if record.DiscoveryExchangeID == exchange.DiscoveryExchangeID {
+					return record, nil
+				}
+			}
+			return exchange, nil
+		},
+		func(record interface{}) (bool, bool) {
+			discoveryExchange, ok := record.(DiscoveryExchangeRecord)
+			return discoveryExchange.Disclose != nil, ok
+		},
+	)
+	record, err := c.waitFor(ctx, w)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return record.(DiscoveryExchangeRecord).Capabilities(), nil
+}
+
+// DiscoverFeaturesV2 queries the features of the agent of the connection with discover-features 2.0
+// and blocks until the disclosures message is received. When both queryProtocol and queryGoalCode are empty,
+// all protocols and goal codes are queried.
+// Like the WaitFor helpers it waits for events when the client has an event bus, and polls otherwise.
+func (c *Client) DiscoverFeaturesV2(ctx context.Context, connectionID string, queryProtocol string, queryGoalCode string) (Capabilities, error) {
+	if queryProtocol == "" && queryGoalCode == "" {
+		queryProtocol, queryGoalCode = "*", "*"
+	}
+	exchange, err := c.WithContext(ctx).QueryFeaturesV2(connectionID, queryProtocol, queryGoalCode)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	var w = discoveryWaiter(TopicDiscoverFeatureV2, exchange.DiscoveryExchangeID,
+		func(c *Client) (interface{}, error) {
+			records, err := c.QueryDiscoveryExchangeRecordsV2(connectionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range records {
+				if record.DiscoveryExchangeID == exchange.DiscoveryExchangeID {
+					return record, nil
+				}
+			}
+			return exchange, nil
+		},
+		func(record interface{}) (bool, bool) {
+			discoveryExchange, ok := record.(DiscoveryExchangeRecordV2)
+			return discoveryExchange.Disclosures != nil, ok
+		},
+	)
+	record, err := c.waitFor(ctx, w)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return record.(DiscoveryExchangeRecordV2).Capabilities(), nil
+}
+
+// discoveryWaiter waits until disclosed reports that the disclosure of the discovery exchange is received
+func discoveryWaiter(topic string, discoveryExchangeID string, fetch func(c *Client) (interface{}, error), disclosed func(record interface{}) (bool, bool)) waiter {
+	return waiter{
+		topic:    topic,
+		recordID: discoveryExchangeID,
+		states:   []string{discoveryDisclosed},
+		fetch:    fetch,
+		status: func(record interface{}) (string, string, bool) {
+			isDisclosed, ok := disclosed(record)
+			if isDisclosed {
+				return discoveryDisclosed, "", ok
+			}
+			return "query-sent", "", ok
+		},
+		terminal: func(state string) bool { return false },
+		failure:  func(state string) bool { return false },
+		next:     func(state string) []string { return nil },
+	}
+}
+
+// Capabilities returns the protocols disclosed to the query, empty until the disclose message is received
+func (r DiscoveryExchangeRecord) Capabilities() Capabilities {
+	var capabilities = newCapabilities()
+	if r.Disclose != nil {
+		for _, protocol := range r.Disclose.Protocols {
+			capabilities.addProtocol(protocol.PID, protocol.Roles)
+		}
+	}
+	return capabilities
+}
+
+// Capabilities returns the protocols and goal codes disclosed to the queries,
+// empty until the disclosures message is received
+func (r DiscoveryExchangeRecordV2) Capabilities() Capabilities {
+	var capabilities = newCapabilities()
+	if r.Disclosures != nil {
+		for _, disclosure := range r.Disclosures.Disclosures {
+			switch disclosure.FeatureType {
+			case FeatureTypeProtocol:
+				capabilities.addProtocol(disclosure.ID, disclosure.Roles)
+			case FeatureTypeGoalCode:
+				capabilities.goalCodes[disclosure.ID] = true
+			}
+		}
+	}
+	return capabilities
+}
+
+// Capabilities is the set of protocols and goal codes disclosed by an agent.
+// Protocols are compared by name and version, so https://didcomm.org/issue-credential/2.0 and
+// did:sov:BzCbsNYhMrjHiqZDTUASHg;spec/issue-credential/2.0 are the same protocol.
+type Capabilities struct {
+	// protocols maps the protocol URIs as disclosed to their roles
+	protocols map[string][]string
+	goalCodes map[string]bool
+}
+
+func newCapabilities() Capabilities {
+	return Capabilities{
+		protocols: map[string][]string{},
+		goalCodes: map[string]bool{},
+	}
+}
+
+func (c Capabilities) addProtocol(pid string, roles []string) {
+	for _, role := range roles {
+		c.protocols[pid] = appendUnique(c.protocols[pid], role)
+	}
+	if _, ok := c.protocols[pid]; !ok {
+		c.protocols[pid] = nil
+	}
+}
+
+// protocol returns the disclosed protocol URI which matches uri, a protocol URI or message type URI
+func (c Capabilities) protocol(uri string) (string, bool) {
+	var name = protocolName(uri)
+	for pid := range c.protocols {
+		disclosed := protocolName(pid)
+		if disclosed == name || strings.HasPrefix(name, disclosed+"/") {
+			return pid, true
+		}
+	}
+	return "", false
+}
+
+// SupportsProtocol reports whether the protocol was disclosed, uri can be a protocol URI like
+// https://didcomm.org/issue-credential/2.0 or the URI of a message type of the protocol
+func (c Capabilities) SupportsProtocol(uri string) bool {
+	_, ok := c.protocol(uri)
+	return ok
+}
+
+// Roles returns the roles disclosed for the protocol, agents are not required to disclose roles
+func (c Capabilities) Roles(uri string) []string {
+	pid, ok := c.protocol(uri)
+	if !ok {
+		return nil
+	}
+	return c.protocols[pid]
+}
+
+func (c Capabilities) SupportsGoalCode(goalCode string) bool {
+	return c.goalCodes[goalCode]
+}
+
+// Protocols returns the disclosed protocol URIs in order
+func (c Capabilities) Protocols() []string {
+	var protocols = make([]string, 0, len(c.protocols))
+	for pid := range c.protocols {
+		protocols = append(protocols, pid)
+	}
+	sort.Strings(protocols)
+	return protocols
+}
+
+// GoalCodes returns the disclosed goal codes in order
+func (c Capabilities) GoalCodes() []string {
+	var goalCodes = make([]string, 0, len(c.goalCodes))
+	for goalCode := range c.goalCodes {
+		goalCodes = append(goalCodes, goalCode)
+	}
+	sort.Strings(goalCodes)
+	return goalCodes
+}
+
+func (c Capabilities) String() string {
+	return fmt.Sprintf("protocols %v, goal codes %v", c.Protocols(), c.GoalCodes())
+}
+
+// protocolName strips the prefix of a protocol or message type URI, which differs between
+// the did:sov and https://didcomm.org notations, for example issue-credential/2.0
+func protocolName(uri string) string {
+	for _, prefix := range []string{"did:sov:BzCbsNYhMrjHiqZDTUASHg;spec/", "https://didcomm.org/"} {
+		if strings.HasPrefix(uri, prefix) {
+			return strings.TrimSuffix(strings.TrimPrefix(uri, prefix), "/")
+		}
+	}
+	return strings.TrimSuffix(uri, "/")
+}
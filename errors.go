@@ -0,0 +1,106 @@
+package acapy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned by every Client method when ACA-py responds with a non-2xx status code
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	// Message is the human readable error extracted from the response body
+	Message string
+	// Body is the raw response body, JSON or plain text depending on the ACA-py endpoint
+	Body      []byte
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	var message = e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("acapy: %s %s: %d %s (request ID %s)", e.Method, e.Path, e.StatusCode, message, e.RequestID)
+	}
+	return fmt.Sprintf("acapy: %s %s: %d %s", e.Method, e.Path, e.StatusCode, message)
+}
+
+// JSON unmarshals the response body into v, for endpoints that return structured errors
+func (e *APIError) JSON(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+func newAPIError(method string, path string, response *AdminResponse) *APIError {
+	return &APIError{
+		Method:     method,
+		Path:       path,
+		StatusCode: response.StatusCode,
+		Status:     fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		Body:       response.Body,
+		Message:    errorMessage(response.Body),
+		RequestID:  response.Header.Get("X-Request-ID"),
+	}
+}
+
+// errorMessage extracts the message from an ACA-py error body.
+// ACA-py returns either {"message": "..."}, {"error": "..."} or plain text like "404: Not Found".
+func errorMessage(body []byte) string {
+	var result = struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, &result); err == nil {
+		if result.Message != "" {
+			return result.Message
+		}
+		if result.Error != "" {
+			return result.Error
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// StatusCode returns the HTTP status code of an *APIError wrapped in err, or 0
+func StatusCode(err error) int {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode
+	}
+	return 0
+}
+
+func IsBadRequest(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
+
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+func IsUnprocessableEntity(err error) bool {
+	return StatusCode(err) == http.StatusUnprocessableEntity
+}
+
+// IsServerError reports whether ACA-py responded with a 5xx status code
+func IsServerError(err error) bool {
+	return StatusCode(err) >= http.StatusInternalServerError
+}
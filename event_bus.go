@@ -0,0 +1,264 @@
+package acapy
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Backpressure decides what happens when an event is published to a channel subscription with a full buffer
+type Backpressure int
+
+const (
+	// DropNewest discards the event that is published, this is the default
+	DropNewest Backpressure = iota
+	// DropOldest discards the oldest event in the buffer to make room for the event that is published
+	DropOldest
+	// Block waits until there is room in the buffer or until the subscription is cancelled.
+	// A slow subscriber blocks all other subscribers and the delivery of webhooks by ACA-py.
+	Block
+)
+
+// EventFilter selects the events a subscriber receives, empty fields match all events
+type EventFilter struct {
+	RecordID     string
+	ConnectionID string
+	ThreadID     string
+	// States matches events in any of the states
+	States []string
+}
+
+func (f EventFilter) matches(event Event) bool {
+	if f.RecordID != "" && f.RecordID != event.RecordID {
+		return false
+	}
+	if f.ConnectionID != "" && f.ConnectionID != event.ConnectionID {
+		return false
+	}
+	if f.ThreadID != "" && f.ThreadID != event.ThreadID {
+		return false
+	}
+	if len(f.States) == 0 {
+		return true
+	}
+	for _, state := range f.States {
+		if state == event.State {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBus delivers events to any number of subscribers, which can subscribe and unsubscribe at runtime.
+// Use Handler as the webhooks handler, or WebhookHandlers to combine the bus with your own handlers.
+//
+// Subscribers either receive events in a callback or on a channel.
+// Callbacks are called synchronously in the order of subscription and should return quickly.
+// Channels have a bounded buffer, when the buffer is full the Backpressure of the subscription
+// decides whether the event is dropped or the publisher waits.
+type EventBus struct {
+	mu            sync.RWMutex
+	subscriptions []*Subscription
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscription is a subscription on an EventBus
+type Subscription struct {
+	// dropped is accessed atomically and must be 64-bit aligned
+	dropped uint64
+
+	bus          *EventBus
+	topic        string
+	filter       EventFilter
+	callback     func(event Event)
+	events       chan Event
+	backpressure Backpressure
+	done         chan struct{}
+	once         sync.Once
+
+	// mu guards sending on and closing events
+	mu     sync.RWMutex
+	closed bool
+
+	// C receives the events of a channel subscription, it is closed on Unsubscribe
+	C <-chan Event
+}
+
+// Subscribe calls callback for every event on topic which matches filter.
+// An empty topic subscribes to all topics.
+func (b *EventBus) Subscribe(topic string, filter EventFilter, callback func(event Event)) *Subscription {
+	subscription := &Subscription{
+		bus:      b,
+		topic:    topic,
+		filter:   filter,
+		callback: callback,
+		done:     make(chan struct{}),
+	}
+	b.add(subscription)
+	return subscription
+}
+
+// SubscribeChan delivers every event on topic which matches filter on the channel C of the subscription.
+// An empty topic subscribes to all topics. The channel has a buffer of size buffer.
+func (b *EventBus) SubscribeChan(topic string, filter EventFilter, buffer int, backpressure Backpressure) *Subscription {
+	events := make(chan Event, buffer)
+	subscription := &Subscription{
+		bus:          b,
+		topic:        topic,
+		filter:       filter,
+		events:       events,
+		backpressure: backpressure,
+		done:         make(chan struct{}),
+		C:            events,
+	}
+	b.add(subscription)
+	return subscription
+}
+
+func (b *EventBus) add(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, subscription)
+}
+
+// Unsubscribe stops the delivery of events and closes C for channel subscriptions.
+// It is safe to call Unsubscribe more than once.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		// Release a publisher which is blocked on this subscription before acquiring the lock
+		close(s.done)
+
+		s.bus.remove(s)
+
+		if s.events != nil {
+			s.mu.Lock()
+			s.closed = true
+			close(s.events)
+			s.mu.Unlock()
+		}
+	})
+}
+
+func (b *EventBus) remove(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range b.subscriptions {
+		if s == subscription {
+			b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+// Dropped returns the number of events which were dropped because the buffer of the channel was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Publish delivers event to all matching subscribers.
+// Callbacks are allowed to subscribe and unsubscribe.
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	for _, subscription := range subscriptions {
+		if subscription.topic != "" && subscription.topic != event.Topic {
+			continue
+		}
+		if !subscription.filter.matches(event) {
+			continue
+		}
+		subscription.deliver(event)
+	}
+}
+
+func (s *Subscription) deliver(event Event) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	if s.callback != nil {
+		s.callback(event)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- event:
+		return
+	default:
+	}
+
+	switch s.backpressure {
+	case Block:
+		select {
+		case s.events <- event:
+		case <-s.done:
+		}
+	case DropOldest:
+		select {
+		case <-s.events:
+			atomic.AddUint64(&s.dropped, 1)
+		default:
+		}
+		select {
+		case s.events <- event:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// Dispatch decodes the raw payload of an event on topic and publishes it
+func (b *EventBus) Dispatch(topic string, body []byte) error {
+	return b.WebhookHandlers().Dispatch(topic, body)
+}
+
+// DispatchWallet decodes the raw payload of an event of the sub-wallet walletID on topic and publishes it
+func (b *EventBus) DispatchWallet(walletID string, topic string, body []byte) error {
+	return b.walletHandlers(walletID).Dispatch(topic, body)
+}
+
+// DispatchEvent publishes an event which has been decoded already
+func (b *EventBus) DispatchEvent(event Event) error {
+	b.Publish(event)
+	return nil
+}
+
+// WebhookHandlers returns handlers which publish every event on the bus,
+// including events on topics which are not modelled by this library.
+// The handlers of topics can be set on the result to handle events directly as well.
+func (b *EventBus) WebhookHandlers() WebhookHandlers {
+	return b.walletHandlers("")
+}
+
+func (b *EventBus) walletHandlers(walletID string) WebhookHandlers {
+	return WebhookHandlers{
+		RawEventHandler: func(topic string, payload json.RawMessage) {},
+		EventHandler: func(topic string, payload interface{}) error {
+			event := NewEvent(topic, payload)
+			event.WalletID = walletID
+			b.Publish(event)
+			return nil
+		},
+	}
+}
+
+// Handler returns a webhooks handler which publishes every event on the bus
+func (b *EventBus) Handler() func(w http.ResponseWriter, r *http.Request) {
+	return CreateWebhooksHandler(b.WebhookHandlers())
+}
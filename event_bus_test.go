@@ -0,0 +1,162 @@
+package acapy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testEvent(recordID string) Event {
+	return Event{Topic: TopicConnections, RecordID: recordID, ConnectionID: recordID}
+}
+
+func receivedRecordIDs(subscription *Subscription) []string {
+	var recordIDs []string
+	for {
+		select {
+		case event := <-subscription.C:
+			recordIDs = append(recordIDs, event.RecordID)
+		default:
+			return recordIDs
+		}
+	}
+}
+
+func TestEventBusBackpressure(t *testing.T) {
+	var tests = []struct {
+		name         string
+		backpressure Backpressure
+		received     []string
+		dropped      uint64
+	}{
+		{name: "drop newest", backpressure: DropNewest, received: []string{"1", "2"}, dropped: 2},
+		{name: "drop oldest", backpressure: DropOldest, received: []string{"3", "4"}, dropped: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bus := NewEventBus()
+			subscription := bus.SubscribeChan(TopicConnections, EventFilter{}, 2, test.backpressure)
+			defer subscription.Unsubscribe()
+
+			for _, recordID := range []string{"1", "2", "3", "4"} {
+				bus.Publish(testEvent(recordID))
+			}
+
+			received := receivedRecordIDs(subscription)
+			if fmt.Sprint(received) != fmt.Sprint(test.received) {
+				t.Errorf("expected events %v, got %v", test.received, received)
+			}
+			if subscription.Dropped() != test.dropped {
+				t.Errorf("expected %d dropped events, got %d", test.dropped, subscription.Dropped())
+			}
+		})
+	}
+}
+
+func TestEventBusBlock(t *testing.T) {
+	bus := NewEventBus()
+	subscription := bus.SubscribeChan(TopicConnections, EventFilter{}, 1, Block)
+	defer subscription.Unsubscribe()
+
+	bus.Publish(testEvent("1"))
+	var published = make(chan struct{})
+	go func() {
+		bus.Publish(testEvent("2"))
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("publish did not wait for room in the buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if event := <-subscription.C; event.RecordID != "1" {
+		t.Errorf("expected event 1, got %s", event.RecordID)
+	}
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publish did not continue after the buffer was read")
+	}
+	if event := <-subscription.C; event.RecordID != "2" {
+		t.Errorf("expected event 2, got %s", event.RecordID)
+	}
+	if subscription.Dropped() != 0 {
+		t.Errorf("expected no dropped events, got %d", subscription.Dropped())
+	}
+}
+
+func TestEventBusUnsubscribeReleasesBlockedPublish(t *testing.T) {
+	bus := NewEventBus()
+	subscription := bus.SubscribeChan(TopicConnections, EventFilter{}, 1, Block)
+	bus.Publish(testEvent("1"))
+
+	var published = make(chan struct{})
+	go func() {
+		bus.Publish(testEvent("2"))
+		close(published)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	subscription.Unsubscribe()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Unsubscribe did not release the blocked publish")
+	}
+
+	// The buffered event can still be read, after which C is closed
+	if event, ok := <-subscription.C; !ok || event.RecordID != "1" {
+		t.Errorf("expected buffered event 1, got %v, %t", event.RecordID, ok)
+	}
+	if _, ok := <-subscription.C; ok {
+		t.Error("expected C to be closed")
+	}
+	subscription.Unsubscribe()
+}
+
+func TestEventBusUnsubscribeInCallback(t *testing.T) {
+	bus := NewEventBus()
+	var received []string
+	var subscription *Subscription
+	subscription = bus.Subscribe(TopicConnections, EventFilter{}, func(event Event) {
+		received = append(received, event.RecordID)
+		subscription.Unsubscribe()
+	})
+	var other []string
+	bus.Subscribe(TopicConnections, EventFilter{}, func(event Event) {
+		other = append(other, event.RecordID)
+		// Subscribing from a callback must not deadlock either
+		bus.Subscribe(TopicBasicMessages, EventFilter{}, func(event Event) {})
+	})
+
+	bus.Publish(testEvent("1"))
+	bus.Publish(testEvent("2"))
+
+	if fmt.Sprint(received) != "[1]" {
+		t.Errorf("expected only event 1 before unsubscribing, got %v", received)
+	}
+	if len(other) != 2 {
+		t.Errorf("expected the other subscriber to receive both events, got %v", other)
+	}
+}
+
+func TestEventBusFilter(t *testing.T) {
+	bus := NewEventBus()
+	subscription := bus.SubscribeChan(TopicConnections, EventFilter{
+		ConnectionID: "1",
+		States:       []string{string(ConnectionStateActive)},
+	}, 10, DropNewest)
+	defer subscription.Unsubscribe()
+
+	bus.Publish(Event{Topic: TopicConnections, RecordID: "a", ConnectionID: "1", State: string(ConnectionStateRequest)})
+	bus.Publish(Event{Topic: TopicConnections, RecordID: "b", ConnectionID: "2", State: string(ConnectionStateActive)})
+	bus.Publish(Event{Topic: TopicBasicMessages, RecordID: "c", ConnectionID: "1", State: string(ConnectionStateActive)})
+	bus.Publish(Event{Topic: TopicConnections, RecordID: "d", ConnectionID: "1", State: string(ConnectionStateActive)})
+
+	if received := receivedRecordIDs(subscription); fmt.Sprint(received) != "[d]" {
+		t.Errorf("expected only event d, got %v", received)
+	}
+}
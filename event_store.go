@@ -0,0 +1,398 @@
+package acapy
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredEvent is an event as it is recorded in an EventStore
+type StoredEvent struct {
+	// Key identifies the event for deduplication, see EventKey
+	Key        string          `json:"key"`
+	Topic      string          `json:"topic"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
+	// Handled is true when the event has been dispatched successfully
+	Handled bool `json:"handled"`
+}
+
+// EventStore records the events received from ACA-py
+type EventStore interface {
+	// Append stores event, it returns false without storing when an event with the same key is already stored
+	Append(event StoredEvent) (bool, error)
+	// Get returns the event with key, the boolean is false when there is no such event
+	Get(key string) (StoredEvent, bool, error)
+	// MarkHandled records that the event with key has been dispatched successfully
+	MarkHandled(key string) error
+	// Events returns the events received at or after since, in the order in which they were received
+	Events(since time.Time) ([]StoredEvent, error)
+}
+
+// EventKey returns the deduplication key of an event: the topic, record ID, state and updated_at of the record.
+// Events without updated_at, like action menus, forwards and keylist updates which reuse the ID of their
+// connection or mediation, and events which do not belong to a record are identified by a hash of the payload.
+func EventKey(event Event, payload []byte) string {
+	if event.UpdatedAt.IsZero() {
+		hash := sha256.Sum256(payload)
+		if event.RecordID == "" {
+			return event.Topic + "/" + hex.EncodeToString(hash[:])
+		}
+		return fmt.Sprintf("%s/%s/%s", event.Topic, event.RecordID, hex.EncodeToString(hash[:]))
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", event.Topic, event.RecordID, event.State, event.UpdatedAt)
+}
+
+// PersistentDispatcher records every event in an EventStore before dispatching it.
+// Events which have been handled before, for example because ACA-py retried a webhook, are not dispatched again.
+// PersistentDispatcher is an EventDispatcher, use Handler to receive webhooks
+// or pass it to StreamEvents to receive events over the WebSocket.
+type PersistentDispatcher struct {
+	store      EventStore
+	dispatcher EventDispatcher
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+func NewPersistentDispatcher(store EventStore, dispatcher EventDispatcher) *PersistentDispatcher {
+	return &PersistentDispatcher{
+		store:      store,
+		dispatcher: dispatcher,
+		inFlight:   map[string]struct{}{},
+	}
+}
+
+// Dispatch records the event and dispatches it, unless it is a duplicate
+func (p *PersistentDispatcher) Dispatch(topic string, body []byte) error {
+	event, err := DecodeEvent(topic, body)
+	if err != nil {
+		return err
+	}
+	key := EventKey(event, body)
+
+	appended, err := p.store.Append(StoredEvent{
+		Key:        key,
+		Topic:      topic,
+		Payload:    body,
+		ReceivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	if !appended {
+		stored, ok, err := p.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if ok && stored.Handled {
+			return nil
+		}
+	}
+	return p.dispatch(key, topic, body)
+}
+
+func (p *PersistentDispatcher) dispatch(key string, topic string, body []byte) error {
+	p.mu.Lock()
+	if _, ok := p.inFlight[key]; ok {
+		// The same event is being dispatched concurrently
+		p.mu.Unlock()
+		return nil
+	}
+	p.inFlight[key] = struct{}{}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.inFlight, key)
+		p.mu.Unlock()
+	}()
+
+	if err := p.dispatcher.Dispatch(topic, body); err != nil {
+		return err
+	}
+	return p.store.MarkHandled(key)
+}
+
+// Handler returns a webhooks handler which records and dispatches every webhook
+func (p *PersistentDispatcher) Handler(onError func(topic string, body []byte, err error)) func(w http.ResponseWriter, r *http.Request) {
+	return webhookHandler(onError, p.Dispatch)
+}
+
+// ReplayUnhandled dispatches the stored events which have not been handled successfully,
+// for example because the process stopped while handling them
+func (p *PersistentDispatcher) ReplayUnhandled() error {
+	events, err := p.store.Events(time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.Handled {
+			continue
+		}
+		if err := p.dispatch(event.Key, event.Topic, event.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayEvents dispatches all events received at or after since to dispatcher, for example
+// to rebuild the state of a WebhookHandlers after a restart
+func ReplayEvents(store EventStore, dispatcher EventDispatcher, since time.Time) error {
+	events, err := store.Events(since)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := dispatcher.Dispatch(event.Topic, event.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileEventStore is an EventStore which appends events to a file with one JSON document per line.
+// All events are kept in memory as well.
+// The file and the memory grow with every event, use Compact to remove old events which have been handled.
+type FileEventStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	events []StoredEvent
+	keys   map[string]int
+}
+
+// fileEventStoreLine is either an event or a marker that the event with HandledKey is handled
+type fileEventStoreLine struct {
+	*StoredEvent
+	HandledKey string `json:"handled_key,omitempty"`
+}
+
+// FileEventStoreOptions configures how a FileEventStore is loaded
+type FileEventStoreOptions struct {
+	// OnCorruptLine is called for every line which cannot be decoded, the line is skipped.
+	// When OnCorruptLine is nil, NewFileEventStoreWithOptions returns a CorruptEventStoreError instead.
+	OnCorruptLine func(err *CorruptEventStoreError)
+}
+
+// CorruptEventStoreError tells that a line of the file of a FileEventStore cannot be decoded
+type CorruptEventStoreError struct {
+	Path string
+	// Line is the line number, starting at 1
+	Line int
+	Data []byte
+	Err  error
+}
+
+func (e *CorruptEventStoreError) Error() string {
+	return fmt.Sprintf("acapy: event store %s: corrupt line %d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *CorruptEventStoreError) Unwrap() error {
+	return e.Err
+}
+
+// NewFileEventStore opens or creates the file at path and loads the events stored in it.
+// It returns a CorruptEventStoreError when a line cannot be decoded.
+func NewFileEventStore(path string) (*FileEventStore, error) {
+	return NewFileEventStoreWithOptions(path, FileEventStoreOptions{})
+}
+
+// NewFileEventStoreWithOptions opens or creates the file at path and loads the events stored in it.
+// A partially written last line, for example after a crash, is truncated from the file.
+func NewFileEventStoreWithOptions(path string, options FileEventStoreOptions) (*FileEventStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	store := &FileEventStore{
+		path: path,
+		file: file,
+		keys: map[string]int{},
+	}
+	if err := store.load(options); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileEventStore) load(options FileEventStoreOptions) error {
+	reader := bufio.NewReaderSize(s.file, 64*1024)
+	var offset int64
+	for number := 1; ; number++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				// Every write ends with a newline, so the last write was interrupted
+				return s.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		var line fileEventStoreLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			corrupt := &CorruptEventStoreError{Path: s.path, Line: number, Data: data, Err: err}
+			if options.OnCorruptLine == nil {
+				return corrupt
+			}
+			options.OnCorruptLine(corrupt)
+			continue
+		}
+		if line.HandledKey != "" {
+			if i, ok := s.keys[line.HandledKey]; ok {
+				s.events[i].Handled = true
+			}
+			continue
+		}
+		if line.StoredEvent != nil {
+			s.keys[line.Key] = len(s.events)
+			s.events = append(s.events, *line.StoredEvent)
+		}
+	}
+}
+
+func (s *FileEventStore) Append(event StoredEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[event.Key]; ok {
+		return false, nil
+	}
+	if err := s.write(fileEventStoreLine{StoredEvent: &event}); err != nil {
+		return false, err
+	}
+	s.keys[event.Key] = len(s.events)
+	s.events = append(s.events, event)
+	return true, nil
+}
+
+func (s *FileEventStore) Get(key string) (StoredEvent, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.keys[key]
+	if !ok {
+		return StoredEvent{}, false, nil
+	}
+	return s.events[i], true, nil
+}
+
+func (s *FileEventStore) MarkHandled(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.keys[key]
+	if !ok || s.events[i].Handled {
+		return nil
+	}
+	if err := s.write(fileEventStoreLine{HandledKey: key}); err != nil {
+		return err
+	}
+	s.events[i].Handled = true
+	return nil
+}
+
+func (s *FileEventStore) Events(since time.Time) ([]StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []StoredEvent
+	for _, event := range s.events {
+		if !event.ReceivedAt.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Compact removes the handled events which were received before before, and rewrites the file
+// with the remaining events. Duplicates of removed events are not recognised anymore,
+// so before should be well past the time in which ACA-py retries webhooks.
+func (s *FileEventStore) Compact(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []StoredEvent
+	for _, event := range s.events {
+		if !event.Handled || !event.ReceivedAt.Before(before) {
+			events = append(events, event)
+		}
+	}
+
+	// Write the remaining events to a new file and replace the old file, so a crash leaves either file intact
+	temp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	writer := bufio.NewWriter(temp)
+	for i := range events {
+		data, err := json.Marshal(fileEventStoreLine{StoredEvent: &events[i]})
+		if err != nil {
+			temp.Close()
+			return err
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	s.events = events
+	s.keys = make(map[string]int, len(events))
+	for i, event := range events {
+		s.keys[event.Key] = i
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (s *FileEventStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// write must be called with s.mu locked
+func (s *FileEventStore) write(line fileEventStoreLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
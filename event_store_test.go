@@ -0,0 +1,322 @@
+package acapy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventKey(t *testing.T) {
+	var tests = []struct {
+		name   string
+		topic  string
+		first  string
+		second string
+		equal  bool
+	}{
+		{
+			name:   "same record state",
+			topic:  TopicConnections,
+			first:  `{"connection_id":"1","state":"active","updated_at":"2021-01-01T00:00:00Z"}`,
+			second: `{"state":"active","connection_id":"1","updated_at":"2021-01-01T00:00:00Z"}`,
+			equal:  true,
+		},
+		{
+			name:   "record updated",
+			topic:  TopicConnections,
+			first:  `{"connection_id":"1","state":"active","updated_at":"2021-01-01T00:00:00Z"}`,
+			second: `{"connection_id":"1","state":"active","updated_at":"2021-01-01T00:00:01Z"}`,
+		},
+		{
+			name:   "retried action menu",
+			topic:  TopicActionMenu,
+			first:  `{"connection_id":"1","menu":{"title":"First"}}`,
+			second: `{"connection_id":"1","menu":{"title":"First"}}`,
+			equal:  true,
+		},
+		{
+			name:   "action menus of a connection",
+			topic:  TopicActionMenu,
+			first:  `{"connection_id":"1","menu":{"title":"First"}}`,
+			second: `{"connection_id":"1","menu":{"title":"Second"}}`,
+		},
+		{
+			name:   "forwards to a recipient key",
+			topic:  TopicForward,
+			first:  `{"connection_id":"1","recipient_key":"key","status":"delivered"}`,
+			second: `{"connection_id":"2","recipient_key":"key","status":"delivered"}`,
+		},
+		{
+			name:   "keylist updates of a mediation",
+			topic:  TopicKeylist,
+			first:  `{"connection_id":"1","mediation_id":"m","updated":[{"recipient_key":"a","action":"add"}]}`,
+			second: `{"connection_id":"1","mediation_id":"m","updated":[{"recipient_key":"b","action":"add"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, err := DecodeEvent(test.topic, []byte(test.first))
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := DecodeEvent(test.topic, []byte(test.second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			firstKey, secondKey := EventKey(first, []byte(test.first)), EventKey(second, []byte(test.second))
+			if (firstKey == secondKey) != test.equal {
+				t.Errorf("expected equal keys to be %t, got %q and %q", test.equal, firstKey, secondKey)
+			}
+		})
+	}
+}
+
+func storedEventLine(key string, handled bool) string {
+	return fmt.Sprintf(`{"key":%q,"topic":"ping","payload":{"connection_id":%q},"received_at":"2021-01-01T00:00:00Z","handled":%t}`+"\n", key, key, handled)
+}
+
+func TestFileEventStoreLoad(t *testing.T) {
+	var tests = []struct {
+		name    string
+		data    string
+		corrupt bool
+		keys    string
+		handled string
+		lines   []int
+		err     int
+		rest    string
+	}{
+		{
+			name:    "events",
+			data:    storedEventLine("a", false) + storedEventLine("b", false) + `{"handled_key":"a"}` + "\n",
+			keys:    "[a b]",
+			handled: "[true false]",
+		},
+		{
+			name:    "torn last line",
+			data:    storedEventLine("a", true) + `{"key":"b","topic":"pi`,
+			keys:    "[a]",
+			handled: "[true]",
+			rest:    storedEventLine("a", true),
+		},
+		{
+			name:    "corrupt line",
+			data:    storedEventLine("a", false) + "{corrupt}\n" + storedEventLine("b", false),
+			corrupt: true,
+			keys:    "[a b]",
+			handled: "[false false]",
+			lines:   []int{2},
+		},
+		{
+			name: "corrupt line without callback",
+			data: storedEventLine("a", false) + "{corrupt}\n" + storedEventLine("b", false),
+			err:  2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			if err := ioutil.WriteFile(path, []byte(test.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			var lines []int
+			var options FileEventStoreOptions
+			if test.corrupt {
+				options.OnCorruptLine = func(err *CorruptEventStoreError) {
+					lines = append(lines, err.Line)
+				}
+			}
+			store, err := NewFileEventStoreWithOptions(path, options)
+			if test.err != 0 {
+				var corrupt *CorruptEventStoreError
+				if !errors.As(err, &corrupt) || corrupt.Line != test.err {
+					t.Fatalf("expected CorruptEventStoreError of line %d, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			events, _ := store.Events(time.Time{})
+			var keys []string
+			var handled []bool
+			for _, event := range events {
+				keys = append(keys, event.Key)
+				handled = append(handled, event.Handled)
+			}
+			if fmt.Sprint(keys) != test.keys || fmt.Sprint(handled) != test.handled {
+				t.Errorf("expected events %s handled %s, got %v handled %v", test.keys, test.handled, keys, handled)
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(test.lines) {
+				t.Errorf("expected corrupt lines %v, got %v", test.lines, lines)
+			}
+			if test.rest != "" {
+				data, _ := ioutil.ReadFile(path)
+				if string(data) != test.rest {
+					t.Errorf("expected the file to be truncated to %q, got %q", test.rest, data)
+				}
+			}
+		})
+	}
+}
+
+func TestFileEventStoreAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	store, err := NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		key      string
+		appended bool
+	}{
+		{key: "a", appended: true},
+		{key: "b", appended: true},
+		{key: "a", appended: false},
+	} {
+		appended, err := store.Append(StoredEvent{Key: test.key, Topic: TopicPing, Payload: []byte(`{}`), ReceivedAt: received})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if appended != test.appended {
+			t.Errorf("expected append of %s to return %t, got %t", test.key, test.appended, appended)
+		}
+		received = received.Add(time.Hour)
+	}
+	if err := store.MarkHandled("b"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	events, _ := store.Events(time.Time{})
+	if len(events) != 2 || events[0].Key != "a" || events[0].Handled || events[1].Key != "b" || !events[1].Handled {
+		t.Errorf("expected unhandled a and handled b after reloading, got %+v", events)
+	}
+	if appended, _ := store.Append(StoredEvent{Key: "a"}); appended {
+		t.Error("expected a duplicate of a reloaded event not to be appended")
+	}
+	if events, _ := store.Events(time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC)); len(events) != 1 || events[0].Key != "b" {
+		t.Errorf("expected only b since 01:00, got %+v", events)
+	}
+}
+
+func TestFileEventStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	store, err := NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { store.Close() }()
+
+	var old = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var recent = old.Add(48 * time.Hour)
+	for _, event := range []struct {
+		key      string
+		received time.Time
+		handled  bool
+	}{
+		{key: "old handled", received: old, handled: true},
+		{key: "old unhandled", received: old},
+		{key: "recent handled", received: recent, handled: true},
+	} {
+		if _, err := store.Append(StoredEvent{Key: event.key, Topic: TopicPing, Payload: []byte(`{}`), ReceivedAt: event.received}); err != nil {
+			t.Fatal(err)
+		}
+		if event.handled {
+			store.MarkHandled(event.key)
+		}
+	}
+
+	if err := store.Compact(old.Add(24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append(StoredEvent{Key: "new", Topic: TopicPing, Payload: []byte(`{}`), ReceivedAt: recent}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := store.Events(time.Time{})
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, event.Key)
+	}
+	if strings.Join(keys, ",") != "old unhandled,recent handled,new" {
+		t.Errorf("expected the old handled event to be removed, got %v", keys)
+	}
+}
+
+func TestPersistentDispatcherReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	store, err := NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dispatched []string
+	var failing = map[string]bool{"Second": true}
+	handler := dispatchFunc(func(topic string, body []byte) error {
+		event, _ := DecodeEvent(topic, body)
+		title := event.Payload.(ActionMenuEvent).Menu.Title
+		dispatched = append(dispatched, title)
+		if failing[title] {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	dispatcher := NewPersistentDispatcher(store, handler)
+	for _, body := range []string{
+		`{"connection_id":"1","menu":{"title":"First"}}`,
+		// ACA-py retries the webhook
+		`{"connection_id":"1","menu":{"title":"First"}}`,
+		`{"connection_id":"1","menu":{"title":"Second"}}`,
+		`{"connection_id":"1","menu":{"title":"Third"}}`,
+	} {
+		dispatcher.Dispatch(TopicActionMenu, []byte(body))
+	}
+	if fmt.Sprint(dispatched) != "[First Second Third]" {
+		t.Errorf("expected every menu to be dispatched once, got %v", dispatched)
+	}
+	store.Close()
+
+	// Restart with a handler which succeeds
+	store, err = NewFileEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	dispatched, failing = nil, nil
+	dispatcher = NewPersistentDispatcher(store, handler)
+	if err := dispatcher.ReplayUnhandled(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(dispatched) != "[Second]" {
+		t.Errorf("expected only the failed menu to be replayed, got %v", dispatched)
+	}
+	if err := dispatcher.ReplayUnhandled(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dispatched) != 1 {
+		t.Errorf("expected the replayed menu to be handled, got %v", dispatched)
+	}
+}
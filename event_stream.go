@@ -0,0 +1,149 @@
+package acapy
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EventStreamOptions configures StreamEvents, zero values are replaced by defaults
+type EventStreamOptions struct {
+	// InitialBackoff is the delay before reconnecting after the connection is lost, 1 second by default
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponentially increasing reconnect delay, 1 minute by default
+	MaxBackoff time.Duration
+	// PingInterval is the interval in which pings are sent, 30 seconds by default.
+	// The connection is considered lost when nothing is received for twice the interval.
+	PingInterval time.Duration
+
+	// OnConnect is called when the connection with ACA-py is established
+	OnConnect func()
+	// OnDisconnect is called when the connection with ACA-py is lost
+	OnDisconnect func(err error)
+	// OnError is called when an event cannot be dispatched, by default the error is logged
+	OnError func(topic string, body []byte, err error)
+}
+
+type eventStreamMessage struct {
+	Topic    string          `json:"topic"`
+	Payload  json.RawMessage `json:"payload"`
+	WalletID string          `json:"wallet_id"`
+}
+
+// StreamEvents connects to the admin WebSocket of ACA-py (/ws) and dispatches every event to dispatcher,
+// which can be a WebhookHandlers or an EventBus. It is an alternative to webhooks
+// for controllers that ACA-py cannot reach, for example when they run behind NAT.
+// In multitenant mode the sub-wallet of every event is passed to dispatchers which implement WalletEventDispatcher,
+// like a TenantWebhookRouter or an EventBus, which sets Event.WalletID.
+// The connection is re-established with exponential backoff when it is lost or ACA-py responds with a 5xx status code.
+// StreamEvents blocks until ctx is cancelled and returns ctx.Err(), or returns the *APIError
+// when ACA-py rejects the connection otherwise, for example with 401 Unauthorized or 404 Not Found.
+func (c *Client) StreamEvents(ctx context.Context, dispatcher EventDispatcher, options EventStreamOptions) error {
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = time.Second
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = time.Minute
+	}
+	if options.PingInterval <= 0 {
+		options.PingInterval = 30 * time.Second
+	}
+	if options.OnError == nil {
+		options.OnError = logWebhookError
+	}
+
+	var backoff = options.InitialBackoff
+	for {
+		connected, err := c.streamEvents(ctx, dispatcher, options)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if options.OnDisconnect != nil {
+			options.OnDisconnect(err)
+		}
+		// Retrying does not help when ACA-py rejects the handshake
+		if StatusCode(err) != 0 && !IsServerError(err) {
+			return err
+		}
+		if connected {
+			backoff = options.InitialBackoff
+		}
+
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+		backoff *= 2
+		if backoff > options.MaxBackoff {
+			backoff = options.MaxBackoff
+		}
+	}
+}
+
+// streamEvents reads events until the connection is lost, it reports whether the connection was established
+func (c *Client) streamEvents(ctx context.Context, dispatcher EventDispatcher, options EventStreamOptions) (bool, error) {
+	var transport = c.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpTransport, _ := transport.(*http.Transport)
+	ws, err := dialWebSocket(ctx, c.webSocketURL(), c.headers(), httpTransport, c.tlsConfig)
+	if err != nil {
+		return false, err
+	}
+	if options.OnConnect != nil {
+		options.OnConnect()
+	}
+
+	var done = make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(options.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// Unblock ReadMessage
+				ws.Close()
+				return
+			case <-done:
+				ws.Close()
+				return
+			case <-ticker.C:
+				_ = ws.Ping()
+			}
+		}
+	}()
+
+	for {
+		ws.SetReadDeadline(time.Now().Add(2 * options.PingInterval))
+		message, err := ws.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+
+		var envelope eventStreamMessage
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			options.OnError("", message, &WebhookDecodeError{Err: err})
+			continue
+		}
+		// The settings topic and heartbeats are not events
+		if envelope.Topic == "settings" || len(envelope.Payload) == 0 {
+			continue
+		}
+		if err := dispatchWallet(dispatcher, envelope.WalletID, envelope.Topic, envelope.Payload); err != nil {
+			options.OnError(envelope.Topic, envelope.Payload, err)
+		}
+	}
+}
+
+func (c *Client) webSocketURL() string {
+	var url = c.url("/ws")
+	if strings.HasPrefix(url, "https://") {
+		return "wss://" + strings.TrimPrefix(url, "https://")
+	}
+	return "ws://" + strings.TrimPrefix(url, "http://")
+}
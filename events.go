@@ -0,0 +1,116 @@
+package acapy
+
+import (
+	"encoding/json"
+)
+
+// Event is an event sent by ACA-py on a topic, together with the identifiers used to filter and order events
+type Event struct {
+	Topic string
+	// Payload is the decoded event, for example a Connection for the connections topic,
+	// or a json.RawMessage for topics which are not modelled by this library
+	Payload interface{}
+
+	// RecordID identifies the record the event belongs to, for example the connection ID,
+	// credential exchange ID or presentation exchange ID
+	RecordID     string
+	ConnectionID string
+	ThreadID     string
+	State        string
+	UpdatedAt    Timestamp
+
+	// WalletID is the sub-wallet the event belongs to when ACA-py runs in multitenant mode,
+	// it is empty for the base wallet and for dispatchers which are not told the wallet
+	WalletID string
+}
+
+// NewEvent creates an Event for a decoded payload and extracts the identifiers from it
+func NewEvent(topic string, payload interface{}) Event {
+	var event = Event{
+		Topic:   topic,
+		Payload: payload,
+	}
+	switch p := payload.(type) {
+	case Connection:
+		event.RecordID, event.ConnectionID, event.State, event.UpdatedAt = p.ConnectionID, p.ConnectionID, string(p.State), p.UpdatedAt
+	case BasicMessagesEvent:
+		event.RecordID, event.ConnectionID, event.State = p.MessageID, p.ConnectionID, p.State
+	case ProblemReportEvent:
+		event.RecordID, event.ThreadID = p.ID, p.Thread.Thid
+	case CredentialExchangeRecord:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.CredentialExchangeID, p.ConnectionID, p.ThreadID, string(p.State), p.UpdatedAt
+	case CredentialExchangeRecordV2:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.CredentialExchangeID, p.ConnectionID, p.ThreadID, string(p.State), p.UpdatedAt
+	case CredentialExchangeDIF:
+		event.RecordID, event.State, event.UpdatedAt = p.CredentialExchangeID, p.State, p.UpdatedAt
+	case CredentialExchangeIndy:
+		event.RecordID, event.UpdatedAt = p.CredentialExchangeID, p.UpdatedAt
+	case CredentialExchangeLDProof:
+		event.RecordID, event.State, event.UpdatedAt = p.CredentialExchangeID, p.State, p.UpdatedAt
+	case RevocationRegistry:
+		event.RecordID, event.State, event.UpdatedAt = p.RevocationRegistryID, string(p.State), p.UpdatedAt
+	case CredentialRevocationRecord:
+		event.RecordID, event.State, event.UpdatedAt = p.RecordID, string(p.State), p.UpdatedAt
+	case PresentationExchangeRecord:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.PresentationExchangeID, p.ConnectionID, p.ThreadID, string(p.State), p.UpdatedAt
+	case PresentationExchangeRecordV2:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.PresentationExchangeID, p.ConnectionID, p.ThreadID, string(p.State), p.UpdatedAt
+	case PingEvent:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State = p.ThreadID, p.ConnectionID, p.ThreadID, string(p.State)
+	case OutOfBandEvent:
+		event.RecordID, event.State, event.UpdatedAt = p.InvitationID, string(p.State), p.UpdatedAt
+	case OutOfBandRecord:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.OutOfBandID, p.ConnectionID, p.AttachThreadID, string(p.State), p.UpdatedAt
+	case MediationRecord:
+		event.RecordID, event.ConnectionID, event.State, event.UpdatedAt = p.MediationID, p.ConnectionID, p.State, p.UpdatedAt
+	case KeylistEvent:
+		event.RecordID, event.ConnectionID, event.ThreadID = p.MediationID, p.ConnectionID, p.ThreadID
+	case ForwardEvent:
+		event.RecordID, event.ConnectionID, event.State = p.RecipientKey, p.ConnectionID, p.Status
+	case EndorseTransactionRecord:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = p.TransactionID, p.ConnectionID, p.ThreadID, p.State, p.UpdatedAt
+	case DiscoveryExchangeRecord:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.UpdatedAt = p.DiscoveryExchangeID, p.ConnectionID, p.ThreadID, p.UpdatedAt
+	case DiscoveryExchangeRecordV2:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.UpdatedAt = p.DiscoveryExchangeID, p.ConnectionID, p.ThreadID, p.UpdatedAt
+	case RevocationNotificationEvent:
+		event.RecordID, event.ThreadID = p.ThreadID, p.ThreadID
+	case ActionMenuEvent:
+		event.RecordID, event.ConnectionID = p.ConnectionID, p.ConnectionID
+	case QuestionAnswerEvent:
+		event.RecordID, event.ConnectionID, event.ThreadID, event.State = p.ThreadID, p.ConnectionID, p.ThreadID, string(p.State)
+	case json.RawMessage:
+		var raw = struct {
+			CredentialExchangeID   string    `json:"cred_ex_id"`
+			PresentationExchangeID string    `json:"pres_ex_id"`
+			ConnectionID           string    `json:"connection_id"`
+			ThreadID               string    `json:"thread_id"`
+			State                  string    `json:"state"`
+			UpdatedAt              Timestamp `json:"updated_at"`
+		}{}
+		_ = json.Unmarshal(p, &raw)
+		event.ConnectionID, event.ThreadID, event.State, event.UpdatedAt = raw.ConnectionID, raw.ThreadID, raw.State, raw.UpdatedAt
+		// The records of the formats of v2 exchanges, like present_proof_v2_0 detail records, belong to the exchange
+		event.RecordID = raw.CredentialExchangeID
+		if event.RecordID == "" {
+			event.RecordID = raw.PresentationExchangeID
+		}
+	}
+	return event
+}
+
+// DecodeEvent decodes the raw payload of an event sent by ACA-py on topic
+func DecodeEvent(topic string, body []byte) (Event, error) {
+	var event Event
+	var handlers = WebhookHandlers{
+		RawEventHandler: func(topic string, payload json.RawMessage) {},
+		EventHandler: func(topic string, payload interface{}) error {
+			event = NewEvent(topic, payload)
+			return nil
+		},
+	}
+	if err := handlers.Dispatch(topic, body); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
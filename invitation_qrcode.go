@@ -0,0 +1,173 @@
+package acapy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// QRCodeFormat is the image format of a rendered QR code
+type QRCodeFormat string
+
+const (
+	QRCodeFormatPNG QRCodeFormat = "png"
+	QRCodeFormatSVG QRCodeFormat = "svg"
+)
+
+func (f QRCodeFormat) contentType() string {
+	if f == QRCodeFormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// QRCodeOptions configures the rendering of QR codes, zero values are replaced by defaults
+type QRCodeOptions struct {
+	// ErrorCorrection is QRErrorCorrectionMedium by default
+	ErrorCorrection QRErrorCorrection
+	// Size is the width and height of the image in pixels, 256 by default
+	Size int
+	// Margin is the quiet zone around the code in modules, 4 by default. Use a negative margin for no quiet zone.
+	Margin int
+}
+
+func (o QRCodeOptions) withDefaults() QRCodeOptions {
+	if o.Size <= 0 {
+		o.Size = 256
+	}
+	if o.Margin == 0 {
+		o.Margin = 4
+	} else if o.Margin < 0 {
+		o.Margin = 0
+	}
+	return o
+}
+
+// RenderQRCode encodes content, usually an invitation URL, as a QR code image
+func RenderQRCode(content string, format QRCodeFormat, options QRCodeOptions) ([]byte, error) {
+	options = options.withDefaults()
+	code, err := EncodeQRCode([]byte(content), options.ErrorCorrection)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case QRCodeFormatPNG, "":
+		return code.PNG(options.Size, options.Margin)
+	case QRCodeFormatSVG:
+		return code.SVG(options.Size, options.Margin), nil
+	default:
+		return nil, fmt.Errorf("acapy: unsupported QR code format %q", format)
+	}
+}
+
+// QRCode renders the invitation URL as a QR code image
+func (r CreateInvitationResponse) QRCode(format QRCodeFormat, options QRCodeOptions) ([]byte, error) {
+	if r.InvitationURL == "" {
+		return nil, errors.New("acapy: invitation has no invitation URL")
+	}
+	return RenderQRCode(r.InvitationURL, format, options)
+}
+
+// QRCode renders the invitation URL as a QR code image
+func (r OutOfBandInvitationResponse) QRCode(format QRCodeFormat, options QRCodeOptions) ([]byte, error) {
+	if r.InvitationURL == "" {
+		return nil, errors.New("acapy: invitation has no invitation URL")
+	}
+	return RenderQRCode(r.InvitationURL, format, options)
+}
+
+// ConnectionlessQRCode renders a connectionless message, like a credential offer or presentation request
+// with a ~service decorator, as a QR code image of its URL on endpoint, see ConnectionlessMessageURL
+func ConnectionlessQRCode(endpoint string, message interface{}, format QRCodeFormat, options QRCodeOptions) ([]byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	messageURL, err := ConnectionlessMessageURL(endpoint, data)
+	if err != nil {
+		return nil, err
+	}
+	return RenderQRCode(messageURL, format, options)
+}
+
+// ErrInvitationNotFound is returned by an InvitationLookup when there is no invitation with the ID
+var ErrInvitationNotFound = errors.New("acapy: invitation not found")
+
+// InvitationLookup returns the invitation URL of the invitation with invitationID
+type InvitationLookup func(ctx context.Context, invitationID string) (string, error)
+
+// QRCodeHandler serves the QR codes of invitations on paths ending with the invitation ID,
+// for example /qr/{invitationID}.png or /qr/{invitationID}.svg. Without an extension a PNG is served.
+// The size in pixels can be overridden with the size query parameter, up to 2048.
+type QRCodeHandler struct {
+	lookup  InvitationLookup
+	options QRCodeOptions
+}
+
+func NewQRCodeHandler(lookup InvitationLookup, options QRCodeOptions) *QRCodeHandler {
+	return &QRCodeHandler{
+		lookup:  lookup,
+		options: options,
+	}
+}
+
+func (h *QRCodeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var invitationID = path.Base(r.URL.Path)
+	var format = QRCodeFormatPNG
+	switch extension := path.Ext(invitationID); extension {
+	case ".png", ".svg":
+		format = QRCodeFormat(strings.TrimPrefix(extension, "."))
+		invitationID = strings.TrimSuffix(invitationID, extension)
+	}
+	if invitationID == "" || invitationID == "." || invitationID == "/" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var options = h.options
+	if size := r.URL.Query().Get("size"); size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil || value <= 0 || value > 2048 {
+			http.Error(w, "invalid size", http.StatusBadRequest)
+			return
+		}
+		options.Size = value
+	}
+
+	invitationURL, err := h.lookup(r.Context(), invitationID)
+	if errors.Is(err, ErrInvitationNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Looking up invitation %q: %v\n", invitationID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	image, err := RenderQRCode(invitationURL, format, options)
+	if err != nil {
+		log.Printf("Rendering QR code of invitation %q: %v\n", invitationID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	// Invitations can be single use, so the image must not be served from a cache after it has been used
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(image)
+	}
+}
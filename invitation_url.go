@@ -0,0 +1,241 @@
+package acapy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ConnectionInvitationType = "did:sov:BzCbsNYhMrjHiqZDTUASHg;spec/connections/1.0/invitation"
+	OutOfBandInvitationType  = "did:sov:BzCbsNYhMrjHiqZDTUASHg;spec/out-of-band/1.0/invitation"
+)
+
+// InvitationProtocol tells which protocol an invitation URL belongs to
+type InvitationProtocol string
+
+const (
+	// InvitationProtocolConnections is an RFC 0160 connection invitation, passed in the c_i query parameter
+	InvitationProtocolConnections InvitationProtocol = "connections"
+	// InvitationProtocolOutOfBand is an RFC 0434 out-of-band invitation, passed in the oob query parameter
+	InvitationProtocolOutOfBand InvitationProtocol = "out-of-band"
+	// InvitationProtocolConnectionless is any other message passed in the d_m query parameter,
+	// for example a connectionless presentation request
+	InvitationProtocolConnectionless InvitationProtocol = "connectionless"
+)
+
+// ErrNotAnInvitationURL is returned when a URL has none of the c_i, oob and d_m query parameters
+var ErrNotAnInvitationURL = errors.New("acapy: not an invitation URL")
+
+// DecodedInvitation is the content of an invitation URL, depending on Protocol
+// either Invitation or OutOfBandInvitation is set
+type DecodedInvitation struct {
+	Protocol            InvitationProtocol
+	Invitation          *Invitation
+	OutOfBandInvitation *OutOfBandInvitation
+	// Message is the decoded JSON message
+	Message json.RawMessage
+}
+
+// ParseInvitationURL decodes an invitation URL with a c_i, oob or d_m query parameter.
+// The base64url encoded value may be padded or not. Short URLs are resolved by ResolveInvitationURL.
+func ParseInvitationURL(invitationURL string) (DecodedInvitation, error) {
+	u, err := url.Parse(strings.TrimSpace(invitationURL))
+	if err != nil {
+		return DecodedInvitation{}, err
+	}
+	query := u.Query()
+	for _, parameter := range []string{"oob", "c_i", "d_m"} {
+		value := query.Get(parameter)
+		if value == "" {
+			continue
+		}
+		message, err := decodeInvitationParameter(value)
+		if err != nil {
+			return DecodedInvitation{}, fmt.Errorf("acapy: decoding %s parameter: %w", parameter, err)
+		}
+		return DecodeInvitation(message)
+	}
+	return DecodedInvitation{}, ErrNotAnInvitationURL
+}
+
+// DecodeInvitation decodes an invitation or connectionless message and detects its protocol by its @type
+func DecodeInvitation(message []byte) (DecodedInvitation, error) {
+	var typed = struct {
+		Type string `json:"@type"`
+	}{}
+	if err := json.Unmarshal(message, &typed); err != nil {
+		return DecodedInvitation{}, err
+	}
+
+	var decoded = DecodedInvitation{Message: json.RawMessage(message)}
+	switch {
+	case strings.HasSuffix(typed.Type, "/connections/1.0/invitation"):
+		decoded.Protocol = InvitationProtocolConnections
+		decoded.Invitation = &Invitation{}
+		if err := json.Unmarshal(message, decoded.Invitation); err != nil {
+			return DecodedInvitation{}, err
+		}
+	case strings.Contains(typed.Type, "/out-of-band/1.") && strings.HasSuffix(typed.Type, "/invitation"):
+		decoded.Protocol = InvitationProtocolOutOfBand
+		decoded.OutOfBandInvitation = &OutOfBandInvitation{}
+		if err := json.Unmarshal(message, decoded.OutOfBandInvitation); err != nil {
+			return DecodedInvitation{}, err
+		}
+	default:
+		decoded.Protocol = InvitationProtocolConnectionless
+	}
+	return decoded, nil
+}
+
+// decodeInvitationParameter accepts base64url and standard base64, with or without padding
+func decodeInvitationParameter(value string) ([]byte, error) {
+	// url.Values decodes + as a space, which is part of the standard base64 alphabet
+	value = strings.TrimRight(strings.ReplaceAll(value, " ", "+"), "=")
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.RawStdEncoding.DecodeString(value)
+}
+
+// InvitationFetcher retrieves a short invitation URL. It returns either the location the short URL
+// redirects to, or the invitation JSON returned by the server.
+type InvitationFetcher func(ctx context.Context, shortURL string) (location string, body []byte, err error)
+
+// HTTPInvitationFetcher returns an InvitationFetcher which requests short URLs with client,
+// or with a client with a timeout of 10 seconds when client is nil. Redirects are not followed.
+func HTTPInvitationFetcher(client *http.Client) InvitationFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	noRedirects := *client
+	noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return func(ctx context.Context, shortURL string) (string, []byte, error) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, shortURL, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		r.Header.Set("Accept", "application/json")
+		response, err := noRedirects.Do(r)
+		if err != nil {
+			return "", nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode >= 300 && response.StatusCode < 400 {
+			location, err := response.Location()
+			if err != nil {
+				return "", nil, err
+			}
+			return location.String(), nil, nil
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+		if err != nil {
+			return "", nil, err
+		}
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return "", nil, fmt.Errorf("acapy: fetching invitation %s: %s", shortURL, response.Status)
+		}
+		return "", body, nil
+	}
+}
+
+// ResolveInvitationURL decodes an invitation URL like ParseInvitationURL, and resolves short URLs
+// without an invitation parameter with fetcher. A nil fetcher uses HTTPInvitationFetcher(nil).
+func ResolveInvitationURL(ctx context.Context, invitationURL string, fetcher InvitationFetcher) (DecodedInvitation, error) {
+	if fetcher == nil {
+		fetcher = HTTPInvitationFetcher(nil)
+	}
+	for redirects := 0; redirects < 10; redirects++ {
+		decoded, err := ParseInvitationURL(invitationURL)
+		if err != ErrNotAnInvitationURL {
+			return decoded, err
+		}
+		location, body, err := fetcher(ctx, invitationURL)
+		if err != nil {
+			return DecodedInvitation{}, err
+		}
+		if location == "" {
+			return DecodeInvitation(body)
+		}
+		invitationURL = location
+	}
+	return DecodedInvitation{}, errors.New("acapy: too many redirects resolving invitation URL")
+}
+
+// ConnectionInvitationURL builds the canonical URL of an RFC 0160 invitation on endpoint,
+// in the same format as ACA-py: endpoint?c_i=<padded base64url>
+func ConnectionInvitationURL(endpoint string, invitation Invitation) (string, error) {
+	if invitation.Type == "" {
+		invitation.Type = ConnectionInvitationType
+	}
+	message, err := json.Marshal(invitation)
+	if err != nil {
+		return "", err
+	}
+	return invitationURL(endpoint, "c_i", base64.URLEncoding.EncodeToString(message))
+}
+
+// OutOfBandInvitationURL builds the canonical URL of an out-of-band invitation on endpoint,
+// in the same format as ACA-py: endpoint?oob=<unpadded base64url>
+func OutOfBandInvitationURL(endpoint string, invitation OutOfBandInvitation) (string, error) {
+	if invitation.Type == "" {
+		invitation.Type = OutOfBandInvitationType
+	}
+	message, err := json.Marshal(invitation)
+	if err != nil {
+		return "", err
+	}
+	return invitationURL(endpoint, "oob", base64.RawURLEncoding.EncodeToString(message))
+}
+
+// ConnectionlessMessageURL builds the URL of a connectionless message, for example a presentation request
+// with a ~service decorator, on endpoint: endpoint?d_m=<unpadded base64url>
+func ConnectionlessMessageURL(endpoint string, message json.RawMessage) (string, error) {
+	return invitationURL(endpoint, "d_m", base64.RawURLEncoding.EncodeToString(message))
+}
+
+func invitationURL(endpoint string, parameter string, value string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	// The value is appended unescaped, base64url only contains characters which are allowed in a query
+	query := u.Query()
+	query.Del(parameter)
+	var rawQuery = query.Encode()
+	if rawQuery != "" {
+		rawQuery += "&"
+	}
+	u.RawQuery = rawQuery + parameter + "=" + value
+	return u.String(), nil
+}
+
+// ReceiveInvitationURL resolves an invitation URL, for example from a scanned QR code,
+// and receives it with ReceiveInvitation or ReceiveOutOfBandInvitation depending on its protocol.
+// Short URLs are resolved with HTTPInvitationFetcher(nil).
+func (c *Client) ReceiveInvitationURL(invitationURL string, autoAccept bool) (Connection, error) {
+	decoded, err := ResolveInvitationURL(c.Context(), invitationURL, nil)
+	if err != nil {
+		return Connection{}, err
+	}
+	switch decoded.Protocol {
+	case InvitationProtocolConnections:
+		return c.ReceiveInvitation(*decoded.Invitation, autoAccept)
+	case InvitationProtocolOutOfBand:
+		return c.ReceiveOutOfBandInvitation(*decoded.OutOfBandInvitation, autoAccept)
+	default:
+		return Connection{}, fmt.Errorf("acapy: cannot receive %s message as invitation", decoded.Protocol)
+	}
+}
@@ -0,0 +1,70 @@
+package acapy
+
+import (
+	"context"
+	"net/http"
+)
+
+// AdminRequest is an outgoing admin API call as seen by a Middleware
+type AdminRequest struct {
+	Method      string
+	Path        string
+	QueryParams map[string]string
+	// Body is the marshalled JSON body, nil when the request has no body
+	Body []byte
+	// Header contains the headers which will be sent, including X-API-KEY
+	Header http.Header
+}
+
+// AdminResponse is the response of an admin API call as seen by a Middleware
+type AdminResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// AdminHandler performs an admin API call
+type AdminHandler func(ctx context.Context, request *AdminRequest) (*AdminResponse, error)
+
+// Middleware wraps every admin API call made by the Client.
+// Middleware is called in the order in which it is registered for the request,
+// and in reverse order for the response. A Middleware can short-circuit the call
+// by returning a response or an error without calling next.
+// When a RetryPolicy is configured, every attempt passes through the middleware.
+//
+// For example:
+//
+//	func AuditLog(next acapy.AdminHandler) acapy.AdminHandler {
+//		return func(ctx context.Context, request *acapy.AdminRequest) (*acapy.AdminResponse, error) {
+//			response, err := next(ctx, request)
+//			if err == nil {
+//				log.Printf("%s %s: %d", request.Method, request.Path, response.StatusCode)
+//			}
+//			return response, err
+//		}
+//	}
+type Middleware func(next AdminHandler) AdminHandler
+
+// WithMiddleware adds middleware to the client
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.Use(middleware...)
+	}
+}
+
+// Use adds middleware to the client, it is called after the middleware that is already registered
+func (c *Client) Use(middleware ...Middleware) *Client {
+	// Do not share the backing array with copies of the client created by WithContext
+	var chain = make([]Middleware, 0, len(c.middleware)+len(middleware))
+	chain = append(chain, c.middleware...)
+	c.middleware = append(chain, middleware...)
+	return c
+}
+
+func (c *Client) handler() AdminHandler {
+	var handler = c.roundTrip
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	return handler
+}
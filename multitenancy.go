@@ -0,0 +1,239 @@
+package acapy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+type CreateWalletRequest struct {
+	WalletName         string   `json:"wallet_name,omitempty"`
+	WalletKey          string   `json:"wallet_key,omitempty"`
+	WalletType         string   `json:"wallet_type,omitempty"` // in_memory / indy / askar
+	Label              string   `json:"label,omitempty"`
+	ImageURL           string   `json:"image_url,omitempty"`
+	WalletWebhookURLs  []string `json:"wallet_webhook_urls,omitempty"`
+	WalletDispatchType string   `json:"wallet_dispatch_type,omitempty"` // default / base / both
+	KeyManagementMode  string   `json:"key_management_mode,omitempty"`  // managed / unmanaged
+}
+
+type UpdateWalletRequest struct {
+	Label              string   `json:"label,omitempty"`
+	ImageURL           string   `json:"image_url,omitempty"`
+	WalletWebhookURLs  []string `json:"wallet_webhook_urls,omitempty"`
+	WalletDispatchType string   `json:"wallet_dispatch_type,omitempty"` // default / base / both
+}
+
+type WalletRecord struct {
+	WalletID          string                 `json:"wallet_id"`
+	KeyManagementMode string                 `json:"key_management_mode"` // managed / unmanaged
+	Settings          map[string]interface{} `json:"settings"`
+	CreatedAt         Timestamp              `json:"created_at"`
+	UpdatedAt         Timestamp              `json:"updated_at"`
+	// Token is only returned when the wallet is created
+	Token string `json:"token,omitempty"`
+}
+
+// MultitenancyClient manages the sub-wallets of an ACA-py agent running in multitenant mode
+// and hands out clients which act on behalf of a single tenant.
+// The tokens of the tenants are cached and refreshed when ACA-py rejects them.
+type MultitenancyClient struct {
+	client *Client
+	tokens *tenantTokens
+}
+
+type tenantTokens struct {
+	mu      sync.Mutex
+	tenants map[string]*tenantToken
+}
+
+type tenantToken struct {
+	mu        sync.Mutex
+	walletKey string
+	token     string
+}
+
+// NewMultitenancyClient uses client, which must be authorized for the base wallet, to manage sub-wallets
+func NewMultitenancyClient(client *Client) *MultitenancyClient {
+	return &MultitenancyClient{
+		client: client,
+		tokens: &tenantTokens{
+			tenants: map[string]*tenantToken{},
+		},
+	}
+}
+
+// WithContext returns a copy of the multitenancy client which uses ctx for all admin API calls.
+// The copy shares the token cache with the original.
+func (m *MultitenancyClient) WithContext(ctx context.Context) *MultitenancyClient {
+	return &MultitenancyClient{
+		client: m.client.WithContext(ctx),
+		tokens: m.tokens,
+	}
+}
+
+func (m *MultitenancyClient) CreateWallet(request CreateWalletRequest) (WalletRecord, error) {
+	var wallet WalletRecord
+	err := m.client.post("/multitenancy/wallet", nil, request, &wallet)
+	if err != nil {
+		return WalletRecord{}, err
+	}
+	tenant := m.tokens.tenant(wallet.WalletID)
+	tenant.mu.Lock()
+	tenant.token = wallet.Token
+	tenant.walletKey = request.WalletKey
+	tenant.mu.Unlock()
+	return wallet, nil
+}
+
+// QueryWallets returns all sub-wallets, or the wallets with the given name
+func (m *MultitenancyClient) QueryWallets(walletName string) ([]WalletRecord, error) {
+	var result = struct {
+		Results []WalletRecord `json:"results"`
+	}{}
+	var queryParams = map[string]string{
+		"wallet_name": walletName,
+	}
+	err := m.client.get("/multitenancy/wallets", queryParams, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+func (m *MultitenancyClient) GetWallet(walletID string) (WalletRecord, error) {
+	var wallet WalletRecord
+	err := m.client.get(fmt.Sprintf("/multitenancy/wallet/%s", walletID), nil, &wallet)
+	if err != nil {
+		return WalletRecord{}, err
+	}
+	return wallet, nil
+}
+
+func (m *MultitenancyClient) UpdateWallet(walletID string, request UpdateWalletRequest) (WalletRecord, error) {
+	var wallet WalletRecord
+	err := m.client.put(fmt.Sprintf("/multitenancy/wallet/%s", walletID), nil, request, &wallet)
+	if err != nil {
+		return WalletRecord{}, err
+	}
+	return wallet, nil
+}
+
+// RemoveWallet removes a sub-wallet, walletKey is only required for unmanaged wallets
+func (m *MultitenancyClient) RemoveWallet(walletID string, walletKey string) error {
+	var body = struct {
+		WalletKey string `json:"wallet_key,omitempty"`
+	}{
+		WalletKey: walletKey,
+	}
+	err := m.client.post(fmt.Sprintf("/multitenancy/wallet/%s/remove", walletID), nil, body, nil)
+	if err != nil {
+		return err
+	}
+	m.tokens.remove(walletID)
+	return nil
+}
+
+// GetWalletToken requests a new token for a sub-wallet, walletKey is only required for unmanaged wallets.
+// The token is cached and used by the client returned by Tenant.
+func (m *MultitenancyClient) GetWalletToken(walletID string, walletKey string) (string, error) {
+	tenant := m.tokens.tenant(walletID)
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+
+	if walletKey != "" {
+		tenant.walletKey = walletKey
+	}
+	return m.refreshToken(m.client.Context(), walletID, tenant)
+}
+
+// SetWalletKey stores the key of an unmanaged wallet, so tokens can be requested for it
+func (m *MultitenancyClient) SetWalletKey(walletID string, walletKey string) {
+	tenant := m.tokens.tenant(walletID)
+	tenant.mu.Lock()
+	tenant.walletKey = walletKey
+	tenant.mu.Unlock()
+}
+
+// Tenant returns a client which acts on behalf of the sub-wallet with walletID.
+// The tenant client shares the HTTP transport, options and middleware of the base client
+// and sends the tenant's token as bearer token, requesting a new token when required.
+func (m *MultitenancyClient) Tenant(walletID string) *Client {
+	tenant := *m.client
+	return tenant.Use(m.bearerToken(walletID))
+}
+
+func (m *MultitenancyClient) bearerToken(walletID string) Middleware {
+	return func(next AdminHandler) AdminHandler {
+		return func(ctx context.Context, request *AdminRequest) (*AdminResponse, error) {
+			token, err := m.token(ctx, walletID, "")
+			if err != nil {
+				return nil, err
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+			response, err := next(ctx, request)
+			if err != nil || response.StatusCode != http.StatusUnauthorized {
+				return response, err
+			}
+
+			// The token is rejected, request a new one and try once more
+			token, err = m.token(ctx, walletID, token)
+			if err != nil {
+				return nil, err
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+			return next(ctx, request)
+		}
+	}
+}
+
+// token returns the cached token of the tenant, or requests a new one when there is none
+// or when the cached token equals the rejected token
+func (m *MultitenancyClient) token(ctx context.Context, walletID string, rejected string) (string, error) {
+	tenant := m.tokens.tenant(walletID)
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+
+	if tenant.token != "" && tenant.token != rejected {
+		return tenant.token, nil
+	}
+	return m.refreshToken(ctx, walletID, tenant)
+}
+
+// refreshToken must be called with tenant.mu locked
+func (m *MultitenancyClient) refreshToken(ctx context.Context, walletID string, tenant *tenantToken) (string, error) {
+	var result = struct {
+		Token string `json:"token"`
+	}{}
+	var body = struct {
+		WalletKey string `json:"wallet_key,omitempty"`
+	}{
+		WalletKey: tenant.walletKey,
+	}
+	err := m.client.request(ctx, http.MethodPost, fmt.Sprintf("/multitenancy/wallet/%s/token", walletID), nil, body, &result)
+	if err != nil {
+		return "", err
+	}
+	tenant.token = result.Token
+	return result.Token, nil
+}
+
+func (t *tenantTokens) tenant(walletID string) *tenantToken {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tenant, ok := t.tenants[walletID]
+	if !ok {
+		tenant = &tenantToken{}
+		t.tenants[walletID] = tenant
+	}
+	return tenant
+}
+
+func (t *tenantTokens) remove(walletID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.tenants, walletID)
+}
@@ -0,0 +1,130 @@
+package acapy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClientOption configures a Client created by NewClient
+type ClientOption func(c *Client)
+
+// WithAPIKey sets the X-API-KEY header on every admin API call, same as SetAPIKey
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithTimeout limits the duration of every admin API call, including reading the response body
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient.Timeout = timeout
+	}
+}
+
+// WithTransport replaces the http.RoundTripper used for admin API calls.
+// TLS options are only applied when the transport is an *http.Transport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient.Transport = transport
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to ACA-py.
+// WithClientCertificates and WithRootCAs are merged into it, regardless of the order of the options.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.tlsConfig = config.Clone()
+	}
+}
+
+// WithClientCertificates adds client certificates for mutual TLS
+func WithClientCertificates(certificates ...tls.Certificate) ClientOption {
+	return func(c *Client) {
+		c.clientCertificates = append(c.clientCertificates, certificates...)
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify the certificate of ACA-py
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(c *Client) {
+		c.rootCAs = pool
+	}
+}
+
+// WithHeader adds a header which is sent with every admin API call
+func WithHeader(key string, value string) ClientOption {
+	return func(c *Client) {
+		if c.defaultHeaders == nil {
+			c.defaultHeaders = http.Header{}
+		}
+		c.defaultHeaders.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every admin API call
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithBasePath prefixes the path of every admin API call,
+// for example when ACA-py runs behind an ingress on https://example.com/agent
+func WithBasePath(basePath string) ClientOption {
+	return func(c *Client) {
+		basePath = strings.Trim(basePath, "/")
+		if basePath != "" {
+			basePath = "/" + basePath
+		}
+		c.basePath = basePath
+	}
+}
+
+// WithTracing is the option equivalent of EnableTracing
+func WithTracing() ClientOption {
+	return func(c *Client) {
+		c.tracing = true
+	}
+}
+
+// WithPreserveExchangeRecords is the option equivalent of PreserveExchangeRecords
+func WithPreserveExchangeRecords() ClientOption {
+	return func(c *Client) {
+		c.preserveExchangeRecords = true
+	}
+}
+
+// WithAutoRespondCredentialOffer is the option equivalent of AutoRespondCredentialOffer
+func WithAutoRespondCredentialOffer() ClientOption {
+	return func(c *Client) {
+		c.autoRespondCredentialOffer = true
+	}
+}
+
+// configureTLS applies the TLS configuration collected from the options to the transport
+func (c *Client) configureTLS() {
+	if c.tlsConfig == nil && len(c.clientCertificates) == 0 && c.rootCAs == nil {
+		return
+	}
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	c.tlsConfig.Certificates = append(c.tlsConfig.Certificates, c.clientCertificates...)
+	if c.rootCAs != nil {
+		c.tlsConfig.RootCAs = c.rootCAs
+	}
+
+	var transport = c.HTTPClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if httpTransport, ok := transport.(*http.Transport); ok {
+		httpTransport = httpTransport.Clone()
+		httpTransport.TLSClientConfig = c.tlsConfig
+		c.HTTPClient.Transport = httpTransport
+	}
+}
@@ -27,13 +27,13 @@ type CreateOutOfBandInvitationRequest struct {
 type OutOfBandInvitationResponse struct {
 	AutoAccept          bool                `json:"auto_accept"`
 	InvitationMessageID string              `json:"invi_msg_id"`
-	UpdatedAt           string              `json:"updated_at"`
-	State               string              `json:"state"`
+	UpdatedAt           Timestamp           `json:"updated_at"`
+	State               OutOfBandState      `json:"state"`
 	InvitationID        string              `json:"invitation_id"`
 	InvitationURL       string              `json:"invitation_url"`
 	Trace               bool                `json:"trace"`
 	MultiUse            bool                `json:"multi_use"`
-	CreatedAt           string              `json:"created_at"`
+	CreatedAt           Timestamp           `json:"created_at"`
 	Invitation          OutOfBandInvitation `json:"invitation"`
 }
 
@@ -70,10 +70,21 @@ func (c *Client) CreateOutOfBandInvitation(request CreateOutOfBandInvitationRequ
 }
 
 func (c *Client) ReceiveOutOfBandInvitation(invitation OutOfBandInvitation, autoAccept bool) (Connection, error) {
+	return c.ReceiveOutOfBandInvitationWithOptions(invitation, ReceiveInvitationOptions{
+		Alias:      invitation.Label,
+		AutoAccept: autoAccept,
+	})
+}
+
+func (c *Client) ReceiveOutOfBandInvitationWithOptions(invitation OutOfBandInvitation, options ReceiveInvitationOptions) (Connection, error) {
 	var result Connection
 	var queryParams = map[string]string{
-		"auto_accept": strconv.FormatBool(autoAccept),
-		"alias":       invitation.Label,
+		"auto_accept":  strconv.FormatBool(options.AutoAccept),
+		"alias":        options.Alias,
+		"mediation_id": options.MediationID,
+	}
+	if options.UseExistingConnection != nil {
+		queryParams["use_existing_connection"] = strconv.FormatBool(*options.UseExistingConnection)
 	}
 	err := c.post("/out-of-band/receive-invitation", queryParams, invitation, &result)
 	if err != nil {
@@ -0,0 +1,230 @@
+package acapy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoEventBus is returned by helpers which wait for events that cannot be polled, like ping responses
+var ErrNoEventBus = errors.New("acapy: client has no event bus, see WithEventBus")
+
+// PingResult is the result of a trust ping which received a response
+type PingResult struct {
+	ThreadID  string
+	RoundTrip time.Duration
+}
+
+// Ping sends a trust ping and waits until the response is received, it returns the round trip time.
+// The responses are received as ping events, so the client needs an event bus and ACA-py must be started
+// with --monitor-ping. When ctx is done before the response is received, ctx.Err() is returned
+// together with the thread ID of the ping.
+func (c *Client) Ping(ctx context.Context, connectionID string, options PingOptions) (PingResult, error) {
+	if c.eventBus == nil {
+		return PingResult{}, ErrNoEventBus
+	}
+
+	// Subscribe before sending the ping, the response can arrive before SendPingWithOptions returns
+	var filter = EventFilter{
+		ConnectionID: connectionID,
+		States:       []string{string(PingStateResponseReceived)},
+	}
+	subscription := c.eventBus.SubscribeChan(TopicPing, filter, 16, DropOldest)
+	defer subscription.Unsubscribe()
+
+	var sent = time.Now()
+	thread, err := c.WithContext(ctx).SendPingWithOptions(connectionID, options)
+	if err != nil {
+		return PingResult{}, err
+	}
+	var result = PingResult{ThreadID: thread.ThreadID}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case event := <-subscription.C:
+			if event.ThreadID == thread.ThreadID {
+				result.RoundTrip = time.Since(sent)
+				return result, nil
+			}
+		}
+	}
+}
+
+// LivenessMonitorOptions configures a LivenessMonitor, zero values are replaced by defaults
+type LivenessMonitorOptions struct {
+	// Interval is the time between two rounds of pings, 1 minute by default
+	Interval time.Duration
+	// Timeout is how long to wait for the response to a ping, 10 seconds by default
+	Timeout time.Duration
+	// Concurrency is the number of connections which are pinged at the same time, 10 by default
+	Concurrency int
+	// Comment is the comment of the pings, "ping" by default
+	Comment string
+	// OnUnresponsive is called by Run for every connection which did not respond in a round
+	OnUnresponsive func(status LivenessStatus)
+	// OnRound is called by Run with the statuses of all connections after every round
+	OnRound func(statuses []LivenessStatus)
+}
+
+// LivenessStatus is the result of pinging a connection
+type LivenessStatus struct {
+	ConnectionID string
+	CheckedAt    time.Time
+	RoundTrip    time.Duration
+	// Err tells why the connection is unresponsive, context.DeadlineExceeded when there was no response
+	// within the timeout, or the error sending the ping. When the ctx of Check is done before the connection
+	// is pinged, Err is the error of ctx and CheckedAt is zero.
+	Err error
+	// LastResponse is when the connection last responded to the monitor, zero when it never responded
+	LastResponse time.Time
+}
+
+func (s LivenessStatus) Responsive() bool {
+	return s.Err == nil
+}
+
+// LivenessMonitor periodically pings a set of connections and reports the connections which do not respond.
+// Like Ping it needs a client with an event bus and ACA-py started with --monitor-ping.
+type LivenessMonitor struct {
+	client  *Client
+	options LivenessMonitorOptions
+
+	mu sync.Mutex
+	// connections maps the monitored connection IDs to the time of their last response
+	connections map[string]time.Time
+}
+
+func NewLivenessMonitor(client *Client, options LivenessMonitorOptions) *LivenessMonitor {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 10
+	}
+	return &LivenessMonitor{
+		client:      client,
+		options:     options,
+		connections: map[string]time.Time{},
+	}
+}
+
+// Add starts monitoring the connections, it can be called while the monitor runs
+func (m *LivenessMonitor) Add(connectionIDs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, connectionID := range connectionIDs {
+		if _, ok := m.connections[connectionID]; !ok {
+			m.connections[connectionID] = time.Time{}
+		}
+	}
+}
+
+// Remove stops monitoring the connections, it can be called while the monitor runs
+func (m *LivenessMonitor) Remove(connectionIDs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, connectionID := range connectionIDs {
+		delete(m.connections, connectionID)
+	}
+}
+
+// Check pings all connections once and returns their statuses ordered by connection ID.
+// When ctx is done, Check returns without pinging the remaining connections.
+func (m *LivenessMonitor) Check(ctx context.Context) []LivenessStatus {
+	m.mu.Lock()
+	var statuses = make([]LivenessStatus, 0, len(m.connections))
+	for connectionID, lastResponse := range m.connections {
+		statuses = append(statuses, LivenessStatus{ConnectionID: connectionID, LastResponse: lastResponse})
+	}
+	m.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ConnectionID < statuses[j].ConnectionID
+	})
+
+	var wg sync.WaitGroup
+	var slots = make(chan struct{}, m.options.Concurrency)
+	for i := range statuses {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			// The remaining connections are not checked in this round
+			for j := i; j < len(statuses); j++ {
+				statuses[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return statuses
+		}
+		wg.Add(1)
+		go func(status *LivenessStatus) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			m.check(ctx, status)
+		}(&statuses[i])
+	}
+	wg.Wait()
+	return statuses
+}
+
+func (m *LivenessMonitor) check(ctx context.Context, status *LivenessStatus) {
+	ctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+
+	result, err := m.client.Ping(ctx, status.ConnectionID, PingOptions{Comment: m.options.Comment})
+	status.CheckedAt = time.Now()
+	status.Err = err
+	if err != nil {
+		return
+	}
+	status.RoundTrip = result.RoundTrip
+	status.LastResponse = status.CheckedAt
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The connection may have been removed during the round
+	if _, ok := m.connections[status.ConnectionID]; ok {
+		m.connections[status.ConnectionID] = status.CheckedAt
+	}
+}
+
+// Run checks all connections every interval, starting immediately, and reports the results
+// to OnUnresponsive and OnRound. Run blocks until ctx is cancelled and returns ctx.Err().
+func (m *LivenessMonitor) Run(ctx context.Context) error {
+	if m.client.eventBus == nil {
+		return ErrNoEventBus
+	}
+	ticker := time.NewTicker(m.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		statuses := m.Check(ctx)
+		// Pings which were interrupted by the cancellation of ctx are not reported
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if m.options.OnUnresponsive != nil {
+			for _, status := range statuses {
+				if !status.Responsive() {
+					m.options.OnUnresponsive(status)
+				}
+			}
+		}
+		if m.options.OnRound != nil {
+			m.options.OnRound(statuses)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -8,22 +8,22 @@ import (
 )
 
 type PresentationExchangeRecord struct {
-	PresentationExchangeID   string                  `json:"presentation_exchange_id"`
-	ConnectionID             string                  `json:"connection_id"`
-	ThreadID                 string                  `json:"thread_id"`
-	State                    string                  `json:"state"`
-	Initiator                string                  `json:"initiator"`
-	Role                     string                  `json:"role"`
-	PresentationProposalDict PresentationProposalMap `json:"presentation_proposal_dict"`
-	PresentationRequest      PresentationRequest     `json:"presentation_request"`
-	PresentationRequestDict  struct{}                `json:"presentation_request_dict"` // TODO ?
-	Presentation             Presentation            `json:"presentation"`
-	Verified                 string                  `json:"verified"`
-	CreatedAt                string                  `json:"created_at"`
-	UpdatedAt                string                  `json:"updated_at"`
-	ErrorMsg                 string                  `json:"error_msg"`
-	AutoPresent              bool                    `json:"auto_present"`
-	Trace                    bool                    `json:"trace"`
+	PresentationExchangeID   string                    `json:"presentation_exchange_id"`
+	ConnectionID             string                    `json:"connection_id"`
+	ThreadID                 string                    `json:"thread_id"`
+	State                    PresentationExchangeState `json:"state"`
+	Initiator                Initiator                 `json:"initiator"`
+	Role                     PresentationExchangeRole  `json:"role"`
+	PresentationProposalDict PresentationProposalMap   `json:"presentation_proposal_dict"`
+	PresentationRequest      PresentationRequest       `json:"presentation_request"`
+	PresentationRequestDict  struct{}                  `json:"presentation_request_dict"` // TODO ?
+	Presentation             Presentation              `json:"presentation"`
+	Verified                 string                    `json:"verified"`
+	CreatedAt                Timestamp                 `json:"created_at"`
+	UpdatedAt                Timestamp                 `json:"updated_at"`
+	ErrorMsg                 string                    `json:"error_msg"`
+	AutoPresent              bool                      `json:"auto_present"`
+	Trace                    bool                      `json:"trace"`
 }
 
 type PresentationProposalMap struct {
@@ -382,8 +382,8 @@ func (c *Client) SendPresentationRequest(request PresentationRequestRequest) (Pr
 
 type QueryPresentationExchangeParams struct {
 	ConnectionID string
-	Role         string
-	State        string
+	Role         PresentationExchangeRole
+	State        PresentationExchangeState
 	ThreadID     string
 }
 
@@ -393,8 +393,8 @@ func (c *Client) QueryPresentationExchange(params QueryPresentationExchangeParam
 	}{}
 	queryParams := map[string]string{
 		"connection_id": params.ConnectionID,
-		"role":          params.Role,
-		"state":         params.State,
+		"role":          string(params.Role),
+		"state":         string(params.State),
 		"thread_id":     params.ThreadID,
 	}
 	err := c.get("/present-proof/records", queryParams, &result)
@@ -408,7 +408,7 @@ func (c *Client) GetPresentationExchangeByID(presentationExchangeID string) (Pre
 	var presentationExchange PresentationExchangeRecord
 	err := c.get(fmt.Sprintf("/present-proof/records/%s", presentationExchangeID), nil, &presentationExchange)
 	if err != nil {
-		return PresentationExchangeRecord{}, nil
+		return PresentationExchangeRecord{}, err
 	}
 	return presentationExchange, nil
 }
@@ -417,7 +417,7 @@ func (c *Client) SendPresentationRequestByID(presentationExchangeID string, requ
 	var presentationExchange PresentationExchangeRecord
 	err := c.post(fmt.Sprintf("/present-proof/records/%s/send-request", presentationExchangeID), nil, request, &presentationExchange)
 	if err != nil {
-		return PresentationExchangeRecord{}, nil
+		return PresentationExchangeRecord{}, err
 	}
 	return presentationExchange, nil
 }
@@ -426,7 +426,7 @@ func (c *Client) SendPresentationByID(presentationExchangeID string, proof Prese
 	var presentationExchange PresentationExchangeRecord
 	err := c.post(fmt.Sprintf("/present-proof/records/%s/send-presentation", presentationExchangeID), nil, proof, &presentationExchange)
 	if err != nil {
-		return PresentationExchangeRecord{}, nil
+		return PresentationExchangeRecord{}, err
 	}
 	return presentationExchange, nil
 }
@@ -435,7 +435,7 @@ func (c *Client) VerifyPresentationByID(presentationExchangeID string) (Presenta
 	var presentationExchange PresentationExchangeRecord
 	err := c.post(fmt.Sprintf("/present-proof/records/%s/verify-presentation", presentationExchangeID), nil, nil, &presentationExchange)
 	if err != nil {
-		return PresentationExchangeRecord{}, nil
+		return PresentationExchangeRecord{}, err
 	}
 	return presentationExchange, nil
 }
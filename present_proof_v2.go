@@ -0,0 +1,32 @@
+package acapy
+
+import "encoding/json"
+
+// PresentationExchangeRecordV2 is sent on the present_proof_v2_0 topic
+type PresentationExchangeRecordV2 struct {
+	PresentationExchangeID string                      `json:"pres_ex_id"`
+	ConnectionID           string                      `json:"connection_id"`
+	ThreadID               string                      `json:"thread_id"`
+	State                  PresentationExchangeStateV2 `json:"state"`
+	Initiator              Initiator                   `json:"initiator"`
+	Role                   PresentationExchangeRole    `json:"role"`
+	PresentationProposal   json.RawMessage             `json:"pres_proposal,omitempty"`
+	PresentationRequest    json.RawMessage             `json:"pres_request,omitempty"`
+	Presentation           json.RawMessage             `json:"pres,omitempty"`
+	ByFormat               PresentationByFormat        `json:"by_format"`
+	Verified               string                      `json:"verified"`
+	VerifiedMessages       []string                    `json:"verified_msgs"`
+	CreatedAt              Timestamp                   `json:"created_at"`
+	UpdatedAt              Timestamp                   `json:"updated_at"`
+	ErrorMsg               string                      `json:"error_msg"`
+	AutoPresent            bool                        `json:"auto_present"`
+	AutoVerify             bool                        `json:"auto_verify"`
+	Trace                  bool                        `json:"trace"`
+}
+
+// PresentationByFormat contains the attachments of the presentation messages by format, indy or dif
+type PresentationByFormat struct {
+	PresentationProposal map[string]json.RawMessage `json:"pres_proposal,omitempty"`
+	PresentationRequest  map[string]json.RawMessage `json:"pres_request,omitempty"`
+	Presentation         map[string]json.RawMessage `json:"pres,omitempty"`
+}
@@ -0,0 +1,592 @@
+// The QR code encoder in this file is derived from the QR Code generator library:
+//
+// Copyright (c) Project Nayuki. (MIT License)
+// https://www.nayuki.io/page/qr-code-generator-library
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+// - The above copyright notice and this permission notice shall be included in
+//   all copies or substantial portions of the Software.
+// - The Software is provided "as is", without warranty of any kind, express or
+//   implied, including but not limited to the warranties of merchantability,
+//   fitness for a particular purpose and noninfringement. In no event shall the
+//   authors or copyright holders be liable for any claim, damages or other
+//   liability, whether in an action of contract, tort or otherwise, arising from,
+//   out of or in connection with the Software or the use or other dealings in the
+//   Software.
+
+package acapy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// A QR code encoder (ISO/IEC 18004) for byte mode, versions 1 to 40, following the structure of
+// Project Nayuki's reference implementation
+
+// QRErrorCorrection is the error correction level of a QR code, a higher level makes the code
+// readable when partially damaged or covered at the cost of a denser code
+type QRErrorCorrection int
+
+const (
+	// QRErrorCorrectionMedium recovers about 15% of the code, this is the default
+	QRErrorCorrectionMedium QRErrorCorrection = iota
+	// QRErrorCorrectionLow recovers about 7% of the code
+	QRErrorCorrectionLow
+	// QRErrorCorrectionQuartile recovers about 25% of the code
+	QRErrorCorrectionQuartile
+	// QRErrorCorrectionHigh recovers about 30% of the code
+	QRErrorCorrectionHigh
+)
+
+// ErrQRCodeTooLong is returned when the content does not fit in a QR code of version 40
+var ErrQRCodeTooLong = errors.New("acapy: content too long for a QR code")
+
+// formatBits are the bits of the level in the format information
+func (l QRErrorCorrection) formatBits() int {
+	switch l {
+	case QRErrorCorrectionLow:
+		return 1
+	case QRErrorCorrectionQuartile:
+		return 3
+	case QRErrorCorrectionHigh:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// index is the index of the level in the tables below, which are ordered L, M, Q, H
+func (l QRErrorCorrection) index() int {
+	switch l {
+	case QRErrorCorrectionLow:
+		return 0
+	case QRErrorCorrectionQuartile:
+		return 2
+	case QRErrorCorrectionHigh:
+		return 3
+	default:
+		return 1
+	}
+}
+
+var qrECCCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var qrErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// QRCode is an encoded QR code, a square grid of dark and light modules
+type QRCode struct {
+	Version int
+	// Size is the number of modules on each side, without quiet zone
+	Size int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// EncodeQRCode encodes content in byte mode in the smallest QR code version that fits
+func EncodeQRCode(content []byte, level QRErrorCorrection) (*QRCode, error) {
+	var version int
+	for version = 1; ; version++ {
+		if version > 40 {
+			return nil, ErrQRCodeTooLong
+		}
+		if qrDataBits(len(content), version) <= qrNumDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	// Segment in byte mode, followed by the terminator and padding
+	var bits qrBitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(content), qrCharCountBits(version))
+	for _, b := range content {
+		bits.append(int(b), 8)
+	}
+	var capacity = qrNumDataCodewords(version, level) * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	data := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+
+	q := &QRCode{
+		Version: version,
+		Size:    version*4 + 17,
+	}
+	q.modules = make([][]bool, q.Size)
+	q.isFunction = make([][]bool, q.Size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.Size)
+		q.isFunction[i] = make([]bool, q.Size)
+	}
+	q.drawFunctionPatterns()
+	q.drawCodewords(q.addECCAndInterleave(data, level))
+
+	// Choose the mask with the lowest penalty
+	var mask, minPenalty = 0, -1
+	for m := 0; m < 8; m++ {
+		q.applyMask(m)
+		q.drawFormatBits(level, m)
+		if penalty := q.penalty(); minPenalty < 0 || penalty < minPenalty {
+			mask, minPenalty = m, penalty
+		}
+		q.applyMask(m) // XOR again to undo
+	}
+	q.applyMask(mask)
+	q.drawFormatBits(level, mask)
+	q.isFunction = nil
+	return q, nil
+}
+
+// Dark reports whether the module at x, y is dark, coordinates outside the code are light
+func (q *QRCode) Dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < q.Size && y < q.Size && q.modules[y][x]
+}
+
+// Image renders the code with margin light modules on each side, scaled to fit in size by size pixels
+func (q *QRCode) Image(size int, margin int) image.Image {
+	var modules = q.Size + 2*margin
+	var scale = size / modules
+	if scale < 1 {
+		scale = 1
+		size = modules
+	}
+	// Center the code when size is not a multiple of the number of modules
+	var offset = (size - modules*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			moduleX, moduleY := (x-offset)/scale-margin, (y-offset)/scale-margin
+			if x >= offset && y >= offset && q.Dark(moduleX, moduleY) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// PNG renders the code as a size by size PNG image with a quiet zone of margin modules
+func (q *QRCode) PNG(size int, margin int) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, q.Image(size, margin)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// SVG renders the code as a size by size SVG image with a quiet zone of margin modules
+func (q *QRCode) SVG(size int, margin int) []byte {
+	var modules = q.Size + 2*margin
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, modules, modules)
+	fmt.Fprintf(&buffer, `<rect width="100%%" height="100%%" fill="#FFFFFF"/><path fill="#000000" d="`)
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				fmt.Fprintf(&buffer, "M%d,%dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	buffer.WriteString(`"/></svg>`)
+	return buffer.Bytes()
+}
+
+func (q *QRCode) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *QRCode) drawFunctionPatterns() {
+	// Timing patterns
+	for i := 0; i < q.Size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns, including the separators
+	for _, center := range [][2]int{{3, 3}, {q.Size - 4, 3}, {3, q.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+					continue
+				}
+				distance := maxInt(absInt(dx), absInt(dy))
+				q.setFunction(x, y, distance != 2 && distance != 4)
+			}
+		}
+	}
+
+	// Alignment patterns, except where they overlap with the finder patterns
+	positions := qrAlignmentPatternPositions(q.Version)
+	n := len(positions)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == 0 && j == 0 || i == 0 && j == n-1 || i == n-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(positions[i]+dx, positions[j]+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format bits, they are drawn after choosing the mask
+	q.drawFormatBits(QRErrorCorrectionMedium, 0)
+	q.drawVersion()
+}
+
+func (q *QRCode) drawFormatBits(level QRErrorCorrection, mask int) {
+	data := level.formatBits()<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+
+	// First copy, around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, qrBit(bits, i))
+	}
+	q.setFunction(8, 7, qrBit(bits, 6))
+	q.setFunction(8, 8, qrBit(bits, 7))
+	q.setFunction(7, 8, qrBit(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, qrBit(bits, i))
+	}
+
+	// Second copy, split between the top right and bottom left finder patterns
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.Size-1-i, 8, qrBit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.Size-15+i, qrBit(bits, i))
+	}
+	q.setFunction(8, q.Size-8, true)
+}
+
+func (q *QRCode) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+	remainder := q.Version
+	for i := 0; i < 12; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+	}
+	bits := q.Version<<12 | remainder
+	for i := 0; i < 18; i++ {
+		bit := qrBit(bits, i)
+		a, b := q.Size-11+i%3, i/3
+		q.setFunction(a, b, bit)
+		q.setFunction(b, a, bit)
+	}
+}
+
+// addECCAndInterleave splits data into blocks, appends the Reed-Solomon codewords to each block and interleaves them
+func (q *QRCode) addECCAndInterleave(data []byte, level QRErrorCorrection) []byte {
+	numBlocks := qrErrorCorrectionBlocks[level.index()][q.Version]
+	blockECCLength := qrECCCodewordsPerBlock[level.index()][q.Version]
+	rawCodewords := qrNumRawDataModules(q.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLength := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLength)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		length := shortBlockLength - blockECCLength
+		if i >= numShortBlocks {
+			length++
+		}
+		block := append([]byte{}, data[k:k+length]...)
+		k += length
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			// Placeholder so all blocks have the same length, skipped when interleaving
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLength-blockECCLength || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords places the codewords in a zigzag from the bottom right, skipping function modules
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < q.Size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vertical
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = qrBit(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the code according to the rules of the specification, a lower score is easier to read
+func (q *QRCode) penalty() int {
+	var result int
+	get := func(vertical bool, line, i int) bool {
+		if vertical {
+			return q.modules[i][line]
+		}
+		return q.modules[line][i]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for line := 0; line < q.Size; line++ {
+			// Runs of five or more modules of the same color
+			run := 1
+			for i := 1; i < q.Size; i++ {
+				if get(vertical, line, i) == get(vertical, line, i-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				result += 3 + run - 5
+			}
+
+			// Patterns which look like finder patterns: 1:1:3:1:1 with four light modules on either side
+			for i := 0; i+11 <= q.Size; i++ {
+				if qrMatches(get, vertical, line, i, qrFinderLikeLeft) || qrMatches(get, vertical, line, i, qrFinderLikeRight) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	// 2x2 blocks of the same color
+	var dark int
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := q.modules[y][x]
+				if c == q.modules[y][x-1] && c == q.modules[y-1][x] && c == q.modules[y-1][x-1] {
+					result += 3
+				}
+			}
+		}
+	}
+
+	// Deviation of the proportion of dark modules from 50%, 10 points per 5%
+	total := q.Size * q.Size
+	result += absInt(dark*100/total-50) / 5 * 10
+	return result
+}
+
+var (
+	qrFinderLikeLeft  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+	qrFinderLikeRight = []bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+func qrMatches(get func(vertical bool, line, i int) bool, vertical bool, line int, start int, pattern []bool) bool {
+	for i, dark := range pattern {
+		if get(vertical, line, start+i) != dark {
+			return false
+		}
+	}
+	return true
+}
+
+// qrAlignmentPatternPositions returns the ascending positions of the alignment patterns on both axes
+func qrAlignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, position := numAlign-1, version*4+17-7; i >= 1; i, position = i-1, position-step {
+		positions[i] = position
+	}
+	return positions
+}
+
+// qrNumRawDataModules returns the number of modules available for data and error correction
+func qrNumRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func qrNumDataCodewords(version int, level QRErrorCorrection) int {
+	return qrNumRawDataModules(version)/8 -
+		qrECCCodewordsPerBlock[level.index()][version]*qrErrorCorrectionBlocks[level.index()][version]
+}
+
+func qrCharCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// qrDataBits returns the number of bits of a byte mode segment with length bytes
+func qrDataBits(length int, version int) int {
+	if length >= 1<<uint(qrCharCountBits(version)) {
+		return 1 << 30
+	}
+	return 4 + qrCharCountBits(version) + length*8
+}
+
+func qrBit(value int, i int) bool {
+	return (value>>uint(i))&1 != 0
+}
+
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, qrBit(value, i))
+	}
+}
+
+// reedSolomonDivisor returns the generator polynomial of degree, without the leading coefficient
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,216 @@
+package acapy
+
+import (
+	"strings"
+	"testing"
+)
+
+// The expected modules are the output of github.com/skip2/go-qrcode, an independent encoder,
+// for contents for which both encoders choose the same mask
+func TestEncodeQRCode(t *testing.T) {
+	var tests = []struct {
+		content string
+		level   QRErrorCorrection
+		version int
+		modules []string
+	}{
+		{
+			content: "hello",
+			level:   QRErrorCorrectionLow,
+			version: 1,
+			modules: []string{
+				"#######..#.##.#######",
+				"#.....#.##.#..#.....#",
+				"#.###.#.##..#.#.###.#",
+				"#.###.#..#.#..#.###.#",
+				"#.###.#.#...#.#.###.#",
+				"#.....#.#..##.#.....#",
+				"#######.#.#.#.#######",
+				"........#####........",
+				"##.#..##.##...###.##.",
+				".#####.###....#....##",
+				"..##.####.#.##...##.#",
+				"...#.#..#..#.....#.##",
+				"....#.##.##.#.#.#....",
+				"........####...##.#.#",
+				"#######.###..#.#.###.",
+				"#.....#..#####.##....",
+				"#.###.#..#.#..###...#",
+				"#.###.#.#.##...#.####",
+				"#.###.#..##.#...#.#.#",
+				"#.....#.###..##......",
+				"#######.#.###..#.#.#.",
+			},
+		},
+		{
+			content: "test",
+			level:   QRErrorCorrectionMedium,
+			version: 1,
+			modules: []string{
+				"#######...#...#######",
+				"#.....#.#.#.#.#.....#",
+				"#.###.#....#..#.###.#",
+				"#.###.#..##...#.###.#",
+				"#.###.#.##.##.#.###.#",
+				"#.....#..#.#..#.....#",
+				"#######.#.#.#.#######",
+				".........##..........",
+				"#.#.#.#...#.#...#..#.",
+				"..####.#..##.#.#...##",
+				"#.#...######.###.####",
+				"...###..######.##..#.",
+				"#.#.#.#..###.###.#.##",
+				"........#.....#..#..#",
+				"#######..#..#...##.##",
+				"#.....#...#...#....#.",
+				"#.###.#.###.#.#.##.##",
+				"#.###.#...##.#.#...#.",
+				"#.###.#.####.###..#.#",
+				"#.....#...####.###.#.",
+				"#######.####.###..###",
+			},
+		},
+		{
+			content: "acapy",
+			level:   QRErrorCorrectionQuartile,
+			version: 1,
+			modules: []string{
+				"#######.###.#.#######",
+				"#.....#..#..#.#.....#",
+				"#.###.#.#...#.#.###.#",
+				"#.###.#.#...#.#.###.#",
+				"#.###.#...##..#.###.#",
+				"#.....#.#.#...#.....#",
+				"#######.#.#.#.#######",
+				"........####.........",
+				".#.#.####..#.###.##.#",
+				"..##.......#..#..#.##",
+				"##.##.#.###..#...#..#",
+				"#......#.##......#..#",
+				".#.##.###.....#.#...#",
+				"........###.#..###..#",
+				"#######.##...#.#..##.",
+				"#.....#.#...##.##....",
+				"#.###.#..##.#.####..#",
+				"#.###.#.####...#...##",
+				"#.###.#..#..#...#.#.#",
+				"#.....#.###..##.#....",
+				"#######....##......#.",
+			},
+		},
+		{
+			content: "didcomm",
+			level:   QRErrorCorrectionHigh,
+			version: 1,
+			modules: []string{
+				"#######..##...#######",
+				"#.....#.....#.#.....#",
+				"#.###.#.##..#.#.###.#",
+				"#.###.#.#..#..#.###.#",
+				"#.###.#..#..#.#.###.#",
+				"#.....#..#..#.#.....#",
+				"#######.#.#.#.#######",
+				"..........##.........",
+				"...##.##..#.#....##..",
+				"..##...#.##..#..#....",
+				".##..#####.#####..###",
+				"##..#.....###.###.#..",
+				"..###.#####..##..#..#",
+				"........#..#..#.####.",
+				"#######.###..##.#.#..",
+				"#.....#..###...#####.",
+				"#.###.#.#...###.##.##",
+				"#.###.#.#.##.#...##..",
+				"#.###.#.......#.#..##",
+				"#.....#...##..#######",
+				"#######..##..#.......",
+			},
+		},
+		{
+			content: "https://example.com/invitation?c_i=eyJAdHlwZSI6ICJkaWQ6c292",
+			level:   QRErrorCorrectionHigh,
+			version: 7,
+			modules: []string{
+				"#######.###.#..##.####.#.#######.#..#.#######",
+				"#.....#.######.#....####..##.......#..#.....#",
+				"#.###.#.##.#.###.#..#.##....#....#.#..#.###.#",
+				"#.###.#..##..#...####.##..########.##.#.###.#",
+				"#.###.#......##.#..######.##.##.#.###.#.###.#",
+				"#.....#.###...###.###...#.##.#.##.....#.....#",
+				"#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######",
+				"........#####.#.#.###...#.#.#.....#..........",
+				"..###.#.#....##.###.#######..###..#.####..###",
+				"#.####.#.##.##.##.#....##.########.###.##.#.#",
+				".#.####..#.#.#..###.##.#.##.#.#..###..#..###.",
+				".#..#..#.##.#######.#..#.##....##.#.#...####.",
+				".#....#.#.....#..#...##.##.#...#..###....##..",
+				"..........##.###.#####..###.####...###.#...##",
+				"......##....#..###....#.#..#...#.###.##.#.##.",
+				"#..#.#.##.##...#.....###.##.#.#.#.#.#..#####.",
+				"##########..#.#..#.#.#.#.#.#.###..#..##..#...",
+				".##.##..#####..###...#.#...#..#.#...##..##..#",
+				"#.#####...#####.#.####.##.#.####.####.#.#.##.",
+				"####...#.##.#..####..#..####.#..####.######..",
+				"....######....#.....#####......#....#####....",
+				".####...#####.##..#.#...#.#.##...#.##...###.#",
+				"#####.#.#..##.##.####.#.#####.#.#.#.#.#.#.##.",
+				"....#...#####..##.#.#...#......#.#..#...#####",
+				".#..#####..#.#####.#######.####..##.#####...#",
+				"######..#....#..######...###.#.#.#.##.#..##.#",
+				"##..########..........#.#..####..#.#...##.##.",
+				"#.#....#....#..##.#.#...#....#....#####.#####",
+				"#...#.####....#....##.##.#.....#..#.##..#.#.#",
+				".##.#....#..##.#....##....###..#...####..##..",
+				".....##.#.#..#.####.##..#....##.###..#.#.#.#.",
+				".#.##....##...#..###.##..#..#.#.###..#...##.#",
+				"....#.#..#....#....#..#.####.#.#....#...###.#",
+				"...##..###....#####.#.###.#.#..#...#####.##.#",
+				"....#.#..####.#.#...####.###.#.######.....##.",
+				".####.....#....#...###.##.#.#..#####.###.##..",
+				"#..##.#.####...#...######.#...##....######.#.",
+				"........##..##.####.#...##..#.#.##.##...#####",
+				"#######..##.###.#.###.#.###....##.###.#.####.",
+				"#.....#...##.#.##..##...##.###.##..##...#.##.",
+				"#.###.#.##..#...#.############.#.########..##",
+				"#.###.#.#####.#..#..##.######.##.......##..##",
+				"#.###.#.#..#..#....##..#.#..####.#.####..##..",
+				"#.....#..##.#.######.#......#.....##...#.##..",
+				"#######..#.##..#......#...##..######.####..#.",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		code, err := EncodeQRCode([]byte(test.content), test.level)
+		if err != nil {
+			t.Fatalf("%q: %v", test.content, err)
+		}
+		if code.Version != test.version || code.Size != len(test.modules) {
+			t.Errorf("%q: expected version %d of size %d, got version %d of size %d", test.content, test.version, len(test.modules), code.Version, code.Size)
+			continue
+		}
+		for y, row := range test.modules {
+			var actual strings.Builder
+			for x := 0; x < code.Size; x++ {
+				if code.Dark(x, y) {
+					actual.WriteByte('#')
+				} else {
+					actual.WriteByte('.')
+				}
+			}
+			if actual.String() != row {
+				t.Errorf("%q: row %d\nexpected %s\ngot      %s", test.content, y, row, actual.String())
+			}
+		}
+	}
+}
+
+func TestEncodeQRCodeTooLong(t *testing.T) {
+	// 2953 bytes is the capacity of version 40 at the low level
+	if _, err := EncodeQRCode(make([]byte, 2953), QRErrorCorrectionLow); err != nil {
+		t.Errorf("expected 2953 bytes to fit, got %v", err)
+	}
+	if _, err := EncodeQRCode(make([]byte, 2954), QRErrorCorrectionLow); err != ErrQRCodeTooLong {
+		t.Errorf("expected ErrQRCodeTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package acapy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type DIDDocument struct {
+	Context            interface{}          `json:"@context,omitempty"`
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod,omitempty"`
+	// PublicKey is used instead of VerificationMethod by DID documents in the older format
+	PublicKey      []VerificationMethod `json:"publicKey,omitempty"`
+	Authentication []json.RawMessage    `json:"authentication,omitempty"`
+	Service        []DIDService         `json:"service,omitempty"`
+}
+
+type VerificationMethod struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Controller      string `json:"controller"`
+	PublicKeyBase58 string `json:"publicKeyBase58,omitempty"`
+}
+
+type DIDService struct {
+	ID              string   `json:"id"`
+	Type            string   `json:"type"`
+	ServiceEndpoint string   `json:"serviceEndpoint"`
+	RecipientKeys   []string `json:"recipientKeys,omitempty"`
+	RoutingKeys     []string `json:"routingKeys,omitempty"`
+	Priority        int      `json:"priority,omitempty"`
+}
+
+// verificationMethod returns the verification method with id, which can be relative to the document, like #key-1
+func (d DIDDocument) verificationMethod(id string) (VerificationMethod, bool) {
+	for _, methods := range [][]VerificationMethod{d.VerificationMethod, d.PublicKey} {
+		for _, method := range methods {
+			if method.ID == id || d.ID+method.ID == id || method.ID == d.ID+id {
+				return method, true
+			}
+		}
+	}
+	return VerificationMethod{}, false
+}
+
+// Verkeys returns the base58 verkeys of the recipient keys of the services of the document,
+// or of its verification methods when the services have no recipient keys
+func (d DIDDocument) Verkeys() []string {
+	var verkeys []string
+	for _, service := range d.Service {
+		for _, key := range service.RecipientKeys {
+			if method, ok := d.verificationMethod(key); ok {
+				key = method.PublicKeyBase58
+			}
+			verkeys = appendUnique(verkeys, key)
+		}
+	}
+	if len(verkeys) > 0 {
+		return verkeys
+	}
+	for _, methods := range [][]VerificationMethod{d.VerificationMethod, d.PublicKey} {
+		for _, method := range methods {
+			if method.PublicKeyBase58 != "" {
+				verkeys = appendUnique(verkeys, method.PublicKeyBase58)
+			}
+		}
+	}
+	return verkeys
+}
+
+// RoutingKeys returns the routing keys of the services of the document
+func (d DIDDocument) RoutingKeys() []string {
+	var routingKeys []string
+	for _, service := range d.Service {
+		for _, key := range service.RoutingKeys {
+			routingKeys = appendUnique(routingKeys, key)
+		}
+	}
+	return routingKeys
+}
+
+type DIDResolution struct {
+	DIDDocument DIDDocument            `json:"did_document"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// ResolveDID resolves a DID with the resolvers configured in ACA-py.
+// Unqualified DIDs, as used by the connections protocol, are resolved as did:sov DIDs.
+func (c *Client) ResolveDID(did string) (DIDResolution, error) {
+	var raw json.RawMessage
+	err := c.get(fmt.Sprintf("/resolver/resolve/%s", url.PathEscape(qualifyDID(did))), nil, &raw)
+	if err != nil {
+		return DIDResolution{}, err
+	}
+
+	var resolution DIDResolution
+	if err := json.Unmarshal(raw, &resolution); err != nil {
+		return DIDResolution{}, err
+	}
+	if resolution.DIDDocument.ID == "" {
+		// ACA-py 0.7.0 returns the DID document without resolution metadata
+		if err := json.Unmarshal(raw, &resolution.DIDDocument); err != nil {
+			return DIDResolution{}, err
+		}
+	}
+	return resolution, nil
+}
+
+func qualifyDID(did string) string {
+	if strings.HasPrefix(did, "did:") {
+		return did
+	}
+	return "did:sov:" + did
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" || containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,138 @@
+package acapy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides if and when a failed admin API call is retried.
+// GET requests are retried automatically, POST and PUT requests that are
+// known to be idempotent (for example PublishRevocations) are only retried
+// when RetryIdempotentRequests is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff, a Retry-After header sent by ACA-py takes precedence
+	MaxBackoff time.Duration
+	// Multiplier is applied to the backoff after every attempt, defaults to 2
+	Multiplier float64
+	// Jitter randomizes the backoff by up to the given fraction, between 0 and 1
+	Jitter float64
+	// RetryableStatusCodes are the status codes which are retried, network errors are always retried
+	RetryableStatusCodes []int
+	// RetryIdempotentRequests enables retries for idempotent POST and PUT requests
+	RetryIdempotentRequests bool
+	// OnRetry is called before waiting for the next attempt
+	OnRetry func(event RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried
+type RetryEvent struct {
+	Method  string
+	Path    string
+	Attempt int
+	Delay   time.Duration
+	// Err is either a network error or an *APIError
+	Err error
+}
+
+// DefaultRetryPolicy retries up to 5 times on 429, 502, 503 and 504 with exponential backoff
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy enables retries of failed admin API calls
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// SetRetryPolicy is the builder equivalent of WithRetryPolicy
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	c.retryPolicy = &policy
+	return c
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, statusCode int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	for _, retryableStatusCode := range p.RetryableStatusCodes {
+		if statusCode == retryableStatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) backoff(attempt int, response *AdminResponse) time.Duration {
+	if response != nil {
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return retryAfter
+		}
+	}
+	var multiplier = p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// parseRetryAfter parses both the delay-seconds and HTTP-date forms of the Retry-After header
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
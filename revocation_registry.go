@@ -5,16 +5,16 @@ import (
 )
 
 type RevocationRegistry struct {
-	UpdatedAt            string                       `json:"updated_at"`
+	UpdatedAt            Timestamp                    `json:"updated_at"`
 	Type                 string                       `json:"revoc_def_type"`
 	PendingPub           []interface{}                `json:"pending_pub"`
-	CreatedAt            string                       `json:"created_at"`
+	CreatedAt            Timestamp                    `json:"created_at"`
 	Tag                  string                       `json:"tag"`
 	RecordID             string                       `json:"record_id"`
 	CredDefID            string                       `json:"cred_def_id"`
 	TailsHash            string                       `json:"tails_hash"`
 	MaxCredNum           int                          `json:"max_cred_num"`
-	State                string                       `json:"state"`
+	State                RevocationRegistryState      `json:"state"`
 	IssuerDid            string                       `json:"issuer_did"`
 	Definition           RevocationRegistryDefinition `json:"revoc_reg_def"`
 	TailsLocalPath       string                       `json:"tails_local_path"`
@@ -51,14 +51,14 @@ type RevocationRegistryEntry struct {
 }
 
 type CredentialRevocationRecord struct {
-	CreatedAt              string `json:"created_at"`
-	UpdatedAt              string `json:"updated_at"`
-	CredentialDefinitionID string `json:"cred_def_id"`
-	CredentialRevocationID string `json:"cred_rev_id"`
-	RecordID               string `json:"record_id"`
-	RevocationRegistryID   string `json:"rev_reg_id"`
-	CredentialExchangeID   string `json:"cred_ex_id"`
-	State                  string `json:"state"` // issued/revoked
+	CreatedAt              Timestamp                 `json:"created_at"`
+	UpdatedAt              Timestamp                 `json:"updated_at"`
+	CredentialDefinitionID string                    `json:"cred_def_id"`
+	CredentialRevocationID string                    `json:"cred_rev_id"`
+	RecordID               string                    `json:"record_id"`
+	RevocationRegistryID   string                    `json:"rev_reg_id"`
+	CredentialExchangeID   string                    `json:"cred_ex_id"`
+	State                  CredentialRevocationState `json:"state"`
 }
 
 func (c *Client) CreateRevocationRegistry(credentialDefinitionID string, maxCredNum int) (RevocationRegistry, error) {
@@ -79,10 +79,10 @@ func (c *Client) CreateRevocationRegistry(credentialDefinitionID string, maxCred
 	return result.RevocationRegistry, nil
 }
 
-func (c *Client) QueryRevocationRegistries(credentialDefinitionID string, state string) ([]string, error) {
+func (c *Client) QueryRevocationRegistries(credentialDefinitionID string, state RevocationRegistryState) ([]string, error) {
 	var queryParams = map[string]string{
 		"cred_def_id": credentialDefinitionID,
-		"state":       state,
+		"state":       string(state),
 	}
 	var result = struct {
 		RevocationRegistryIDs []string `json:"rev_reg_ids"`
@@ -131,7 +131,7 @@ func (c *Client) GetActiveRevocationRegistry(credentialDefinitionID string) (Rev
 }
 
 func (c *Client) DownloadRegistryTailsFile(revocationRegistryID string) ([]byte, error) {
-	tailsFile, err := c.getFile(fmt.Sprintf("/revocation/registry/%s/tails-file", revocationRegistryID))
+	tailsFile, err := c.getFile(c.Context(), fmt.Sprintf("/revocation/registry/%s/tails-file", revocationRegistryID))
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +139,7 @@ func (c *Client) DownloadRegistryTailsFile(revocationRegistryID string) ([]byte,
 }
 
 func (c *Client) UploadRegistryTailsFile(revocationRegistryID string) error {
-	return c.put(fmt.Sprintf("/revocation/registry/%s/tails-file", revocationRegistryID))
+	return c.put(fmt.Sprintf("/revocation/registry/%s/tails-file", revocationRegistryID), nil, nil, nil)
 }
 
 func (c *Client) PublishRevocationRegistryDefinition(revocationRegistryID string) (RevocationRegistry, error) {
@@ -164,12 +164,12 @@ func (c *Client) PublishRevocationRegistryEntry(revocationRegistryID string) (Re
 	return result.RevocationRegistry, nil
 }
 
-func (c *Client) SetRevocationRegistryState(revocationRegistryID string, state string) (RevocationRegistry, error) {
+func (c *Client) SetRevocationRegistryState(revocationRegistryID string, state RevocationRegistryState) (RevocationRegistry, error) {
 	var result = struct {
 		RevocationRegistry RevocationRegistry `json:"result"`
 	}{}
 	var queryParams = map[string]string{
-		"state": state,
+		"state": string(state),
 	}
 	err := c.patch(fmt.Sprintf("/revocation/registry/%s/set-state", revocationRegistryID), queryParams, nil, &result)
 	if err != nil {
@@ -213,7 +213,7 @@ func (c *Client) PublishRevocations(revocations PendingRevocations) error {
 	}{
 		Body: revocations,
 	}
-	return c.post("/revocation/publish-revocations", nil, body, nil)
+	return c.postIdempotent("/revocation/publish-revocations", nil, body, nil)
 }
 
 // ClearPendingRevocations
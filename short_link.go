@@ -0,0 +1,213 @@
+package acapy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShortLink is an invitation stored by a ShortLinkServer
+type ShortLink struct {
+	ID string
+	// InvitationURL is the full invitation URL the short URL redirects to
+	InvitationURL string
+	// Invitation is the invitation message, served to wallets which request JSON
+	Invitation json.RawMessage
+	CreatedAt  time.Time
+	// ExpiresAt is zero when the short link does not expire
+	ExpiresAt time.Time
+}
+
+// ShortLinkStore stores the short links of a ShortLinkServer
+type ShortLinkStore interface {
+	Save(ctx context.Context, link ShortLink) error
+	// Load returns ErrInvitationNotFound when there is no short link with id
+	Load(ctx context.Context, id string) (ShortLink, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryShortLinkStore is a ShortLinkStore which keeps short links in memory, expired links are removed when loaded
+type MemoryShortLinkStore struct {
+	mu    sync.Mutex
+	links map[string]ShortLink
+}
+
+func NewMemoryShortLinkStore() *MemoryShortLinkStore {
+	return &MemoryShortLinkStore{
+		links: map[string]ShortLink{},
+	}
+}
+
+func (s *MemoryShortLinkStore) Save(ctx context.Context, link ShortLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links[link.ID] = link
+	return nil
+}
+
+func (s *MemoryShortLinkStore) Load(ctx context.Context, id string) (ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	link, ok := s.links[id]
+	if !ok {
+		return ShortLink{}, ErrInvitationNotFound
+	}
+	if !link.ExpiresAt.IsZero() && time.Now().After(link.ExpiresAt) {
+		delete(s.links, id)
+		return ShortLink{}, ErrInvitationNotFound
+	}
+	return link, nil
+}
+
+func (s *MemoryShortLinkStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.links, id)
+	return nil
+}
+
+// ShortLinkOptions configures a ShortLinkServer, zero values are replaced by defaults
+type ShortLinkOptions struct {
+	// Store is a MemoryShortLinkStore by default
+	Store ShortLinkStore
+	// TTL is the duration after which short links expire, by default they do not expire
+	TTL time.Duration
+}
+
+// ShortLinkServer issues short URLs for invitations, so large invitations still fit in a QR code that can be scanned.
+// It serves the short URLs as wallets expect: with a 302 redirect to the full invitation URL,
+// or with the invitation message when the request has an Accept: application/json header.
+// Mount the server on the path of baseURL, for example:
+//
+//	shortLinks := acapy.NewShortLinkServer("https://example.com/i/", acapy.ShortLinkOptions{})
+//	http.Handle("/i/", shortLinks)
+type ShortLinkServer struct {
+	baseURL string
+	store   ShortLinkStore
+	ttl     time.Duration
+}
+
+func NewShortLinkServer(baseURL string, options ShortLinkOptions) *ShortLinkServer {
+	if options.Store == nil {
+		options.Store = NewMemoryShortLinkStore()
+	}
+	return &ShortLinkServer{
+		baseURL: strings.TrimRight(baseURL, "/") + "/",
+		store:   options.Store,
+		ttl:     options.TTL,
+	}
+}
+
+// Shorten stores the invitation and returns its short URL
+func (s *ShortLinkServer) Shorten(ctx context.Context, invitationURL string) (string, error) {
+	decoded, err := ParseInvitationURL(invitationURL)
+	if err != nil {
+		return "", err
+	}
+	id, err := newShortLinkID()
+	if err != nil {
+		return "", err
+	}
+
+	var link = ShortLink{
+		ID:            id,
+		InvitationURL: invitationURL,
+		Invitation:    decoded.Message,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if s.ttl > 0 {
+		link.ExpiresAt = link.CreatedAt.Add(s.ttl)
+	}
+	if err := s.store.Save(ctx, link); err != nil {
+		return "", err
+	}
+	return s.ShortURL(id), nil
+}
+
+// ShortURL returns the short URL of the short link with id
+func (s *ShortLinkServer) ShortURL(id string) string {
+	return s.baseURL + id
+}
+
+// Lookup returns the short URL of the short link with id. It is an InvitationLookup,
+// so a QRCodeHandler can serve QR codes of short URLs:
+//
+//	http.Handle("/qr/", acapy.NewQRCodeHandler(shortLinks.Lookup, acapy.QRCodeOptions{}))
+func (s *ShortLinkServer) Lookup(ctx context.Context, id string) (string, error) {
+	if _, err := s.store.Load(ctx, id); err != nil {
+		return "", err
+	}
+	return s.ShortURL(id), nil
+}
+
+// CreateInvitation creates a connection invitation with client, see Client.CreateInvitation, and returns its short URL
+func (s *ShortLinkServer) CreateInvitation(client *Client, alias string, autoAccept bool, multiUse bool, public bool) (CreateInvitationResponse, string, error) {
+	invitation, err := client.CreateInvitation(alias, autoAccept, multiUse, public)
+	if err != nil {
+		return CreateInvitationResponse{}, "", err
+	}
+	shortURL, err := s.Shorten(client.Context(), invitation.InvitationURL)
+	if err != nil {
+		return CreateInvitationResponse{}, "", err
+	}
+	return invitation, shortURL, nil
+}
+
+// CreateOutOfBandInvitation creates an out-of-band invitation with client, see Client.CreateOutOfBandInvitation,
+// and returns its short URL
+func (s *ShortLinkServer) CreateOutOfBandInvitation(client *Client, request CreateOutOfBandInvitationRequest, autoAccept bool, multiUse bool) (OutOfBandInvitationResponse, string, error) {
+	invitation, err := client.CreateOutOfBandInvitation(request, autoAccept, multiUse)
+	if err != nil {
+		return OutOfBandInvitationResponse{}, "", err
+	}
+	shortURL, err := s.Shorten(client.Context(), invitation.InvitationURL)
+	if err != nil {
+		return OutOfBandInvitationResponse{}, "", err
+	}
+	return invitation, shortURL, nil
+}
+
+func (s *ShortLinkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	link, err := s.store.Load(r.Context(), path.Base(r.URL.Path))
+	if errors.Is(err, ErrInvitationNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Loading short link %q: %v\n", r.URL.Path, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(link.Invitation)
+		}
+		return
+	}
+	http.Redirect(w, r, link.InvitationURL, http.StatusFound)
+}
+
+func newShortLinkID() (string, error) {
+	var id = make([]byte, 9)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
@@ -0,0 +1,495 @@
+package acapy
+
+// ConnectionState is the RFC 0160 state of a connection, as reported in the state field
+type ConnectionState string
+
+const (
+	ConnectionStateInit       ConnectionState = "init"
+	ConnectionStateInvitation ConnectionState = "invitation"
+	ConnectionStateRequest    ConnectionState = "request"
+	ConnectionStateResponse   ConnectionState = "response"
+	ConnectionStateActive     ConnectionState = "active"
+	ConnectionStateError      ConnectionState = "error"
+	ConnectionStateInactive   ConnectionState = "inactive"
+)
+
+var connectionStateTransitions = map[ConnectionState][]ConnectionState{
+	ConnectionStateInit:       {ConnectionStateInvitation, ConnectionStateRequest, ConnectionStateError},
+	ConnectionStateInvitation: {ConnectionStateRequest, ConnectionStateError},
+	ConnectionStateRequest:    {ConnectionStateResponse, ConnectionStateActive, ConnectionStateError},
+	ConnectionStateResponse:   {ConnectionStateActive, ConnectionStateError},
+	ConnectionStateActive:     {ConnectionStateInactive, ConnectionStateError},
+}
+
+// IsTerminal reports whether the connection protocol is finished
+func (s ConnectionState) IsTerminal() bool {
+	return s == ConnectionStateActive || s == ConnectionStateError || s == ConnectionStateInactive
+}
+
+func (s ConnectionState) IsFailure() bool {
+	return s == ConnectionStateError
+}
+
+// Next returns the states which can follow s
+func (s ConnectionState) Next() []ConnectionState {
+	return connectionStateTransitions[s]
+}
+
+func (s ConnectionState) CanTransitionTo(next ConnectionState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// RFC23State is the RFC 0023 (DID Exchange) state of a connection, as reported in the rfc23_state field
+type RFC23State string
+
+const (
+	RFC23StateStart              RFC23State = "start"
+	RFC23StateInvitationSent     RFC23State = "invitation-sent"
+	RFC23StateInvitationReceived RFC23State = "invitation-received"
+	RFC23StateRequestSent        RFC23State = "request-sent"
+	RFC23StateRequestReceived    RFC23State = "request-received"
+	RFC23StateResponseSent       RFC23State = "response-sent"
+	RFC23StateResponseReceived   RFC23State = "response-received"
+	RFC23StateCompleted          RFC23State = "completed"
+	RFC23StateAbandoned          RFC23State = "abandoned"
+)
+
+var rfc23StateTransitions = map[RFC23State][]RFC23State{
+	RFC23StateStart:              {RFC23StateInvitationSent, RFC23StateInvitationReceived, RFC23StateRequestSent, RFC23StateAbandoned},
+	RFC23StateInvitationSent:     {RFC23StateRequestReceived, RFC23StateAbandoned},
+	RFC23StateInvitationReceived: {RFC23StateRequestSent, RFC23StateAbandoned},
+	RFC23StateRequestSent:        {RFC23StateResponseReceived, RFC23StateAbandoned},
+	RFC23StateRequestReceived:    {RFC23StateResponseSent, RFC23StateAbandoned},
+	RFC23StateResponseSent:       {RFC23StateCompleted, RFC23StateAbandoned},
+	RFC23StateResponseReceived:   {RFC23StateCompleted, RFC23StateAbandoned},
+}
+
+func (s RFC23State) IsTerminal() bool {
+	return s == RFC23StateCompleted || s == RFC23StateAbandoned
+}
+
+func (s RFC23State) IsFailure() bool {
+	return s == RFC23StateAbandoned
+}
+
+// Next returns the states which can follow s
+func (s RFC23State) Next() []RFC23State {
+	return rfc23StateTransitions[s]
+}
+
+func (s RFC23State) CanTransitionTo(next RFC23State) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnectionRole is the role of the other party in a connection
+type ConnectionRole string
+
+const (
+	ConnectionRoleInviter   ConnectionRole = "inviter"
+	ConnectionRoleInvitee   ConnectionRole = "invitee"
+	ConnectionRoleRequester ConnectionRole = "requester"
+	ConnectionRoleResponder ConnectionRole = "responder"
+)
+
+// Initiator tells which party started a connection or exchange
+type Initiator string
+
+const (
+	InitiatorSelf     Initiator = "self"
+	InitiatorExternal Initiator = "external"
+	// InitiatorMultiUse is only used for connections created from a multi-use invitation
+	InitiatorMultiUse Initiator = "multiuse"
+)
+
+// CredentialExchangeState is the state of an issue-credential v1.0 exchange
+type CredentialExchangeState string
+
+const (
+	CredentialExchangeStateProposalSent       CredentialExchangeState = "proposal_sent"
+	CredentialExchangeStateProposalReceived   CredentialExchangeState = "proposal_received"
+	CredentialExchangeStateOfferSent          CredentialExchangeState = "offer_sent"
+	CredentialExchangeStateOfferReceived      CredentialExchangeState = "offer_received"
+	CredentialExchangeStateRequestSent        CredentialExchangeState = "request_sent"
+	CredentialExchangeStateRequestReceived    CredentialExchangeState = "request_received"
+	CredentialExchangeStateCredentialIssued   CredentialExchangeState = "credential_issued"
+	CredentialExchangeStateCredentialReceived CredentialExchangeState = "credential_received"
+	CredentialExchangeStateCredentialAcked    CredentialExchangeState = "credential_acked"
+	CredentialExchangeStateCredentialRevoked  CredentialExchangeState = "credential_revoked"
+	CredentialExchangeStateAbandoned          CredentialExchangeState = "abandoned"
+)
+
+var credentialExchangeStateTransitions = map[CredentialExchangeState][]CredentialExchangeState{
+	CredentialExchangeStateProposalSent:       {CredentialExchangeStateOfferReceived, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateProposalReceived:   {CredentialExchangeStateOfferSent, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateOfferSent:          {CredentialExchangeStateRequestReceived, CredentialExchangeStateProposalReceived, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateOfferReceived:      {CredentialExchangeStateRequestSent, CredentialExchangeStateProposalSent, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateRequestSent:        {CredentialExchangeStateCredentialReceived, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateRequestReceived:    {CredentialExchangeStateCredentialIssued, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateCredentialIssued:   {CredentialExchangeStateCredentialAcked, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateCredentialReceived: {CredentialExchangeStateCredentialAcked, CredentialExchangeStateAbandoned},
+	CredentialExchangeStateCredentialAcked:    {CredentialExchangeStateCredentialRevoked},
+}
+
+// IsTerminal reports whether the exchange is finished, an acked credential can still be revoked
+func (s CredentialExchangeState) IsTerminal() bool {
+	switch s {
+	case CredentialExchangeStateCredentialAcked, CredentialExchangeStateCredentialRevoked, CredentialExchangeStateAbandoned:
+		return true
+	}
+	return false
+}
+
+func (s CredentialExchangeState) IsFailure() bool {
+	return s == CredentialExchangeStateAbandoned
+}
+
+// Next returns the states which can follow s
+func (s CredentialExchangeState) Next() []CredentialExchangeState {
+	return credentialExchangeStateTransitions[s]
+}
+
+func (s CredentialExchangeState) CanTransitionTo(next CredentialExchangeState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialExchangeStateV2 is the state of an issue-credential v2.0 exchange
+type CredentialExchangeStateV2 string
+
+const (
+	CredentialExchangeStateV2ProposalSent       CredentialExchangeStateV2 = "proposal-sent"
+	CredentialExchangeStateV2ProposalReceived   CredentialExchangeStateV2 = "proposal-received"
+	CredentialExchangeStateV2OfferSent          CredentialExchangeStateV2 = "offer-sent"
+	CredentialExchangeStateV2OfferReceived      CredentialExchangeStateV2 = "offer-received"
+	CredentialExchangeStateV2RequestSent        CredentialExchangeStateV2 = "request-sent"
+	CredentialExchangeStateV2RequestReceived    CredentialExchangeStateV2 = "request-received"
+	CredentialExchangeStateV2CredentialIssued   CredentialExchangeStateV2 = "credential-issued"
+	CredentialExchangeStateV2CredentialReceived CredentialExchangeStateV2 = "credential-received"
+	CredentialExchangeStateV2Done               CredentialExchangeStateV2 = "done"
+	CredentialExchangeStateV2CredentialRevoked  CredentialExchangeStateV2 = "credential-revoked"
+	CredentialExchangeStateV2Abandoned          CredentialExchangeStateV2 = "abandoned"
+	// CredentialExchangeStateV2Deleted is sent when the record is removed, for example because of auto_remove
+	CredentialExchangeStateV2Deleted CredentialExchangeStateV2 = "deleted"
+)
+
+var credentialExchangeStateV2Transitions = map[CredentialExchangeStateV2][]CredentialExchangeStateV2{
+	CredentialExchangeStateV2ProposalSent:       {CredentialExchangeStateV2OfferReceived, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2ProposalReceived:   {CredentialExchangeStateV2OfferSent, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2OfferSent:          {CredentialExchangeStateV2RequestReceived, CredentialExchangeStateV2ProposalReceived, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2OfferReceived:      {CredentialExchangeStateV2RequestSent, CredentialExchangeStateV2ProposalSent, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2RequestSent:        {CredentialExchangeStateV2CredentialReceived, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2RequestReceived:    {CredentialExchangeStateV2CredentialIssued, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2CredentialIssued:   {CredentialExchangeStateV2Done, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2CredentialReceived: {CredentialExchangeStateV2Done, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2Done:               {CredentialExchangeStateV2CredentialRevoked, CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2CredentialRevoked:  {CredentialExchangeStateV2Deleted},
+	CredentialExchangeStateV2Abandoned:          {CredentialExchangeStateV2Deleted},
+}
+
+// IsTerminal reports whether the exchange is finished, a done credential can still be revoked
+func (s CredentialExchangeStateV2) IsTerminal() bool {
+	switch s {
+	case CredentialExchangeStateV2Done, CredentialExchangeStateV2CredentialRevoked, CredentialExchangeStateV2Abandoned, CredentialExchangeStateV2Deleted:
+		return true
+	}
+	return false
+}
+
+func (s CredentialExchangeStateV2) IsFailure() bool {
+	return s == CredentialExchangeStateV2Abandoned
+}
+
+// Next returns the states which can follow s
+func (s CredentialExchangeStateV2) Next() []CredentialExchangeStateV2 {
+	return credentialExchangeStateV2Transitions[s]
+}
+
+func (s CredentialExchangeStateV2) CanTransitionTo(next CredentialExchangeStateV2) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialExchangeRole is the role of this agent in an issue-credential exchange
+type CredentialExchangeRole string
+
+const (
+	CredentialExchangeRoleIssuer CredentialExchangeRole = "issuer"
+	CredentialExchangeRoleHolder CredentialExchangeRole = "holder"
+)
+
+// PresentationExchangeState is the state of a present-proof v1.0 exchange
+type PresentationExchangeState string
+
+const (
+	PresentationExchangeStateProposalSent         PresentationExchangeState = "proposal_sent"
+	PresentationExchangeStateProposalReceived     PresentationExchangeState = "proposal_received"
+	PresentationExchangeStateRequestSent          PresentationExchangeState = "request_sent"
+	PresentationExchangeStateRequestReceived      PresentationExchangeState = "request_received"
+	PresentationExchangeStatePresentationSent     PresentationExchangeState = "presentation_sent"
+	PresentationExchangeStatePresentationReceived PresentationExchangeState = "presentation_received"
+	PresentationExchangeStateVerified             PresentationExchangeState = "verified"
+	PresentationExchangeStatePresentationAcked    PresentationExchangeState = "presentation_acked"
+	PresentationExchangeStateAbandoned            PresentationExchangeState = "abandoned"
+)
+
+var presentationExchangeStateTransitions = map[PresentationExchangeState][]PresentationExchangeState{
+	PresentationExchangeStateProposalSent:         {PresentationExchangeStateRequestReceived, PresentationExchangeStateAbandoned},
+	PresentationExchangeStateProposalReceived:     {PresentationExchangeStateRequestSent, PresentationExchangeStateAbandoned},
+	PresentationExchangeStateRequestSent:          {PresentationExchangeStatePresentationReceived, PresentationExchangeStateProposalReceived, PresentationExchangeStateAbandoned},
+	PresentationExchangeStateRequestReceived:      {PresentationExchangeStatePresentationSent, PresentationExchangeStateProposalSent, PresentationExchangeStateAbandoned},
+	PresentationExchangeStatePresentationSent:     {PresentationExchangeStatePresentationAcked, PresentationExchangeStateAbandoned},
+	PresentationExchangeStatePresentationReceived: {PresentationExchangeStateVerified, PresentationExchangeStateAbandoned},
+}
+
+// IsTerminal reports whether the exchange is finished, verified for the verifier and presentation_acked for the prover
+func (s PresentationExchangeState) IsTerminal() bool {
+	switch s {
+	case PresentationExchangeStateVerified, PresentationExchangeStatePresentationAcked, PresentationExchangeStateAbandoned:
+		return true
+	}
+	return false
+}
+
+func (s PresentationExchangeState) IsFailure() bool {
+	return s == PresentationExchangeStateAbandoned
+}
+
+// Next returns the states which can follow s
+func (s PresentationExchangeState) Next() []PresentationExchangeState {
+	return presentationExchangeStateTransitions[s]
+}
+
+func (s PresentationExchangeState) CanTransitionTo(next PresentationExchangeState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PresentationExchangeStateV2 is the state of a present-proof v2.0 exchange
+type PresentationExchangeStateV2 string
+
+const (
+	PresentationExchangeStateV2ProposalSent         PresentationExchangeStateV2 = "proposal-sent"
+	PresentationExchangeStateV2ProposalReceived     PresentationExchangeStateV2 = "proposal-received"
+	PresentationExchangeStateV2RequestSent          PresentationExchangeStateV2 = "request-sent"
+	PresentationExchangeStateV2RequestReceived      PresentationExchangeStateV2 = "request-received"
+	PresentationExchangeStateV2PresentationSent     PresentationExchangeStateV2 = "presentation-sent"
+	PresentationExchangeStateV2PresentationReceived PresentationExchangeStateV2 = "presentation-received"
+	PresentationExchangeStateV2Done                 PresentationExchangeStateV2 = "done"
+	PresentationExchangeStateV2Abandoned            PresentationExchangeStateV2 = "abandoned"
+	// PresentationExchangeStateV2Deleted is sent when the record is removed, for example because of auto_remove
+	PresentationExchangeStateV2Deleted PresentationExchangeStateV2 = "deleted"
+)
+
+var presentationExchangeStateV2Transitions = map[PresentationExchangeStateV2][]PresentationExchangeStateV2{
+	PresentationExchangeStateV2ProposalSent:         {PresentationExchangeStateV2RequestReceived, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2ProposalReceived:     {PresentationExchangeStateV2RequestSent, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2RequestSent:          {PresentationExchangeStateV2PresentationReceived, PresentationExchangeStateV2ProposalReceived, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2RequestReceived:      {PresentationExchangeStateV2PresentationSent, PresentationExchangeStateV2ProposalSent, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2PresentationSent:     {PresentationExchangeStateV2Done, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2PresentationReceived: {PresentationExchangeStateV2Done, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2Done:                 {PresentationExchangeStateV2Deleted},
+	PresentationExchangeStateV2Abandoned:            {PresentationExchangeStateV2Deleted},
+}
+
+func (s PresentationExchangeStateV2) IsTerminal() bool {
+	switch s {
+	case PresentationExchangeStateV2Done, PresentationExchangeStateV2Abandoned, PresentationExchangeStateV2Deleted:
+		return true
+	}
+	return false
+}
+
+func (s PresentationExchangeStateV2) IsFailure() bool {
+	return s == PresentationExchangeStateV2Abandoned
+}
+
+// Next returns the states which can follow s
+func (s PresentationExchangeStateV2) Next() []PresentationExchangeStateV2 {
+	return presentationExchangeStateV2Transitions[s]
+}
+
+func (s PresentationExchangeStateV2) CanTransitionTo(next PresentationExchangeStateV2) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PresentationExchangeRole is the role of this agent in a present-proof exchange
+type PresentationExchangeRole string
+
+const (
+	PresentationExchangeRoleProver   PresentationExchangeRole = "prover"
+	PresentationExchangeRoleVerifier PresentationExchangeRole = "verifier"
+)
+
+// OutOfBandState is the state of an out-of-band invitation or record
+type OutOfBandState string
+
+const (
+	OutOfBandStateInitial         OutOfBandState = "initial"
+	OutOfBandStatePrepareResponse OutOfBandState = "prepare-response"
+	OutOfBandStateAwaitResponse   OutOfBandState = "await-response"
+	// OutOfBandStateAwaitResponseV06 is used by the invitation records of ACA-py 0.6
+	OutOfBandStateAwaitResponseV06 OutOfBandState = "await_response"
+	OutOfBandStateReuseNotAccepted OutOfBandState = "reuse-not-accepted"
+	OutOfBandStateReuseAccepted    OutOfBandState = "reuse-accepted"
+	OutOfBandStateDone             OutOfBandState = "done"
+	OutOfBandStateDeleted          OutOfBandState = "deleted"
+)
+
+var outOfBandStateTransitions = map[OutOfBandState][]OutOfBandState{
+	OutOfBandStateInitial:          {OutOfBandStateAwaitResponse, OutOfBandStateAwaitResponseV06, OutOfBandStatePrepareResponse, OutOfBandStateDone, OutOfBandStateDeleted},
+	OutOfBandStatePrepareResponse:  {OutOfBandStateReuseAccepted, OutOfBandStateReuseNotAccepted, OutOfBandStateDone, OutOfBandStateDeleted},
+	OutOfBandStateAwaitResponse:    {OutOfBandStateDone, OutOfBandStateDeleted},
+	OutOfBandStateAwaitResponseV06: {OutOfBandStateDone},
+	OutOfBandStateReuseNotAccepted: {OutOfBandStateDone, OutOfBandStateDeleted},
+	OutOfBandStateReuseAccepted:    {OutOfBandStateDone, OutOfBandStateDeleted},
+	OutOfBandStateDone:             {OutOfBandStateDeleted},
+}
+
+func (s OutOfBandState) IsTerminal() bool {
+	return s == OutOfBandStateDone || s == OutOfBandStateDeleted
+}
+
+// IsFailure reports whether the other agent did not accept the reuse of an existing connection
+func (s OutOfBandState) IsFailure() bool {
+	return s == OutOfBandStateReuseNotAccepted
+}
+
+// Next returns the states which can follow s
+func (s OutOfBandState) Next() []OutOfBandState {
+	return outOfBandStateTransitions[s]
+}
+
+func (s OutOfBandState) CanTransitionTo(next OutOfBandState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// OutOfBandRole is the role of this agent in an out-of-band exchange
+type OutOfBandRole string
+
+const (
+	OutOfBandRoleSender   OutOfBandRole = "sender"
+	OutOfBandRoleReceiver OutOfBandRole = "receiver"
+)
+
+// RevocationRegistryState is the state of a revocation registry
+type RevocationRegistryState string
+
+const (
+	RevocationRegistryStateInit      RevocationRegistryState = "init"
+	RevocationRegistryStateGenerated RevocationRegistryState = "generated"
+	RevocationRegistryStatePosted    RevocationRegistryState = "posted"
+	RevocationRegistryStateActive    RevocationRegistryState = "active"
+	RevocationRegistryStateFull      RevocationRegistryState = "full"
+	// RevocationRegistryStateDecommissioned is used by ACA-py 0.7.4 and later
+	RevocationRegistryStateDecommissioned RevocationRegistryState = "decommissioned"
+)
+
+var revocationRegistryStateTransitions = map[RevocationRegistryState][]RevocationRegistryState{
+	RevocationRegistryStateInit:      {RevocationRegistryStateGenerated, RevocationRegistryStateDecommissioned},
+	RevocationRegistryStateGenerated: {RevocationRegistryStatePosted, RevocationRegistryStateDecommissioned},
+	RevocationRegistryStatePosted:    {RevocationRegistryStateActive, RevocationRegistryStateDecommissioned},
+	RevocationRegistryStateActive:    {RevocationRegistryStateFull, RevocationRegistryStateDecommissioned},
+	RevocationRegistryStateFull:      {RevocationRegistryStateDecommissioned},
+}
+
+// IsTerminal reports whether no more credentials can be issued against the registry
+func (s RevocationRegistryState) IsTerminal() bool {
+	return s == RevocationRegistryStateFull || s == RevocationRegistryStateDecommissioned
+}
+
+// IsFailure is always false, a registry is decommissioned at the normal end of its life, for example when it is rotated
+func (s RevocationRegistryState) IsFailure() bool {
+	return false
+}
+
+// Next returns the states which can follow s
+func (s RevocationRegistryState) Next() []RevocationRegistryState {
+	return revocationRegistryStateTransitions[s]
+}
+
+func (s RevocationRegistryState) CanTransitionTo(next RevocationRegistryState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CredentialRevocationState is the state of an issued credential in a revocation registry
+type CredentialRevocationState string
+
+const (
+	CredentialRevocationStateIssued  CredentialRevocationState = "issued"
+	CredentialRevocationStateRevoked CredentialRevocationState = "revoked"
+)
+
+var credentialRevocationStateTransitions = map[CredentialRevocationState][]CredentialRevocationState{
+	CredentialRevocationStateIssued: {CredentialRevocationStateRevoked},
+}
+
+func (s CredentialRevocationState) IsTerminal() bool {
+	return s == CredentialRevocationStateRevoked
+}
+
+// IsFailure is always false, revoking a credential is not a failure
+func (s CredentialRevocationState) IsFailure() bool {
+	return false
+}
+
+// Next returns the states which can follow s
+func (s CredentialRevocationState) Next() []CredentialRevocationState {
+	return credentialRevocationStateTransitions[s]
+}
+
+func (s CredentialRevocationState) CanTransitionTo(next CredentialRevocationState) bool {
+	for _, state := range s.Next() {
+		if state == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PingState is the state of a trust ping in a ping event, ping events are only sent when
+// ACA-py is started with --monitor-ping
+type PingState string
+
+const (
+	// PingStateReceived is the state of a ping received from the other agent
+	PingStateReceived PingState = "received"
+	// PingStateResponseReceived is the state of a ping sent by this agent when the response is received
+	PingStateResponseReceived PingState = "response_received"
+)
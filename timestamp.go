@@ -0,0 +1,75 @@
+package acapy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TimestampFormat is the format in which ACA-py stores the created_at and updated_at of records
+const TimestampFormat = "2006-01-02 15:04:05.000000Z"
+
+// timestampLayouts are tried in order when parsing, ACA-py 0.7 and later also emit ISO 8601 timestamps
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// Timestamp is a point in time as sent by ACA-py, for example 2021-01-01 12:00:00.123456Z.
+// It embeds time.Time, so timestamps can be compared and subtracted directly:
+//
+//	age := time.Since(record.UpdatedAt.Time)
+//	if record.CreatedAt.Before(cutoff) { ... }
+type Timestamp struct {
+	time.Time
+}
+
+// ParseTimestamp parses a timestamp in the format of ACA-py or in ISO 8601, timestamps without a zone are UTC
+func ParseTimestamp(value string) (Timestamp, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Timestamp{Time: t}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("acapy: cannot parse timestamp %q", value)
+}
+
+// String formats the timestamp in UTC in the format of ACA-py, or returns an empty string for the zero timestamp
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TimestampFormat)
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON accepts null and empty strings as the zero timestamp
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	parsed, err := ParseTimestamp(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
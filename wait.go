@@ -0,0 +1,315 @@
+package acapy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultPollInterval = time.Second
+
+// eventPollFactor multiplies the poll interval when waiting for events, the record is still polled
+// in case events are missed, for example when they were dropped or sent while the event stream reconnected
+const eventPollFactor = 10
+
+// ErrRecordRemoved is returned by the WaitFor helpers when the record is deleted before it reaches one of the states,
+// for example because ACA-py removes exchanges which are done when auto_remove is set
+var ErrRecordRemoved = errors.New("acapy: record was removed")
+
+// StateError is returned by the WaitFor helpers when a record reaches a failure state, for example when
+// the other agent abandoned the exchange with a problem report, or a terminal state other than the target states
+type StateError struct {
+	Topic    string
+	RecordID string
+	State    string
+	// ErrorMessage is the error_msg of the record, if any
+	ErrorMessage string
+}
+
+func (e *StateError) Error() string {
+	if e.ErrorMessage != "" {
+		return fmt.Sprintf("acapy: %s record %s reached state %s: %s", e.Topic, e.RecordID, e.State, e.ErrorMessage)
+	}
+	return fmt.Sprintf("acapy: %s record %s reached state %s", e.Topic, e.RecordID, e.State)
+}
+
+// WithEventBus lets the WaitFor helpers subscribe to bus instead of polling ACA-py.
+// The bus must receive the events of ACA-py, either as webhooks handler or through StreamEvents.
+func WithEventBus(bus *EventBus) ClientOption {
+	return func(c *Client) {
+		c.eventBus = bus
+	}
+}
+
+// SetEventBus is the builder equivalent of WithEventBus
+func (c *Client) SetEventBus(bus *EventBus) *Client {
+	c.eventBus = bus
+	return c
+}
+
+// WithPollInterval sets the interval in which the WaitFor helpers poll ACA-py when there is no event bus, 1 second by default.
+// With an event bus the helpers poll 10 times less often, in case events are missed.
+func WithPollInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// WaitForConnectionState blocks until the connection reaches one of states, for example ConnectionStateActive.
+// It returns a *StateError when the connection fails or can no longer reach any of states.
+func (c *Client) WaitForConnectionState(ctx context.Context, connectionID string, states ...ConnectionState) (Connection, error) {
+	var w = waiter{
+		topic:    TopicConnections,
+		recordID: connectionID,
+		fetch: func(c *Client) (interface{}, error) {
+			return c.GetConnection(connectionID)
+		},
+		status: func(record interface{}) (string, string, bool) {
+			connection, ok := record.(Connection)
+			return string(connection.State), connection.ErrorMsg, ok
+		},
+		terminal: func(state string) bool { return ConnectionState(state).IsTerminal() },
+		failure:  func(state string) bool { return ConnectionState(state).IsFailure() },
+		next: func(state string) []string {
+			var next []string
+			for _, s := range ConnectionState(state).Next() {
+				next = append(next, string(s))
+			}
+			return next
+		},
+	}
+	for _, state := range states {
+		w.states = append(w.states, string(state))
+	}
+	record, err := c.waitFor(ctx, w)
+	connection, _ := record.(Connection)
+	return connection, err
+}
+
+// WaitForCredentialExchangeState blocks until the issue-credential v1.0 exchange reaches one of states,
+// for example CredentialExchangeStateCredentialAcked.
+// It returns a *StateError when the exchange is abandoned or can no longer reach any of states.
+func (c *Client) WaitForCredentialExchangeState(ctx context.Context, credentialExchangeID string, states ...CredentialExchangeState) (CredentialExchangeRecord, error) {
+	var w = waiter{
+		topic:    TopicIssueCredential,
+		recordID: credentialExchangeID,
+		fetch: func(c *Client) (interface{}, error) {
+			return c.GetCredentialExchange(credentialExchangeID)
+		},
+		status: func(record interface{}) (string, string, bool) {
+			credentialExchange, ok := record.(CredentialExchangeRecord)
+			return string(credentialExchange.State), credentialExchange.ErrorMessage, ok
+		},
+		terminal: func(state string) bool { return CredentialExchangeState(state).IsTerminal() },
+		failure:  func(state string) bool { return CredentialExchangeState(state).IsFailure() },
+		next: func(state string) []string {
+			var next []string
+			for _, s := range CredentialExchangeState(state).Next() {
+				next = append(next, string(s))
+			}
+			return next
+		},
+	}
+	for _, state := range states {
+		w.states = append(w.states, string(state))
+	}
+	record, err := c.waitFor(ctx, w)
+	credentialExchange, _ := record.(CredentialExchangeRecord)
+	return credentialExchange, err
+}
+
+// WaitForCredentialExchangeStateV2 blocks until the issue-credential v2.0 exchange reaches one of states,
+// for example CredentialExchangeStateV2Done.
+// It returns a *StateError when the exchange is abandoned, deleted or can no longer reach any of states.
+func (c *Client) WaitForCredentialExchangeStateV2(ctx context.Context, credentialExchangeID string, states ...CredentialExchangeStateV2) (CredentialExchangeRecordV2, error) {
+	var w = waiter{
+		topic:    TopicIssueCredentialV2,
+		recordID: credentialExchangeID,
+		fetch: func(c *Client) (interface{}, error) {
+			result, err := c.GetCredentialExchangeV2(credentialExchangeID)
+			if err != nil {
+				return nil, err
+			}
+			return result.CredentialExchangeRecord, nil
+		},
+		status: func(record interface{}) (string, string, bool) {
+			credentialExchange, ok := record.(CredentialExchangeRecordV2)
+			return string(credentialExchange.State), credentialExchange.ErrorMessage, ok
+		},
+		terminal: func(state string) bool { return CredentialExchangeStateV2(state).IsTerminal() },
+		failure:  func(state string) bool { return CredentialExchangeStateV2(state).IsFailure() },
+		next: func(state string) []string {
+			var next []string
+			for _, s := range CredentialExchangeStateV2(state).Next() {
+				next = append(next, string(s))
+			}
+			return next
+		},
+	}
+	for _, state := range states {
+		w.states = append(w.states, string(state))
+	}
+	record, err := c.waitFor(ctx, w)
+	credentialExchange, _ := record.(CredentialExchangeRecordV2)
+	return credentialExchange, err
+}
+
+// WaitForPresentationState blocks until the present-proof v1.0 exchange reaches one of states,
+// for example PresentationExchangeStateVerified.
+// It returns a *StateError when the exchange is abandoned or can no longer reach any of states.
+func (c *Client) WaitForPresentationState(ctx context.Context, presentationExchangeID string, states ...PresentationExchangeState) (PresentationExchangeRecord, error) {
+	var w = waiter{
+		topic:    TopicPresentProof,
+		recordID: presentationExchangeID,
+		fetch: func(c *Client) (interface{}, error) {
+			return c.GetPresentationExchangeByID(presentationExchangeID)
+		},
+		status: func(record interface{}) (string, string, bool) {
+			presentationExchange, ok := record.(PresentationExchangeRecord)
+			return string(presentationExchange.State), presentationExchange.ErrorMsg, ok
+		},
+		terminal: func(state string) bool { return PresentationExchangeState(state).IsTerminal() },
+		failure:  func(state string) bool { return PresentationExchangeState(state).IsFailure() },
+		next: func(state string) []string {
+			var next []string
+			for _, s := range PresentationExchangeState(state).Next() {
+				next = append(next, string(s))
+			}
+			return next
+		},
+	}
+	for _, state := range states {
+		w.states = append(w.states, string(state))
+	}
+	record, err := c.waitFor(ctx, w)
+	presentationExchange, _ := record.(PresentationExchangeRecord)
+	return presentationExchange, err
+}
+
+// waiter describes how to wait for a record of a single type, states are passed as strings
+type waiter struct {
+	topic    string
+	recordID string
+	states   []string
+	// fetch gets the current record from ACA-py
+	fetch func(c *Client) (interface{}, error)
+	// status returns the state and error message of a record, ok is false when record is of another type
+	status   func(record interface{}) (state string, errorMessage string, ok bool)
+	terminal func(state string) bool
+	failure  func(state string) bool
+	next     func(state string) []string
+}
+
+// check reports whether record is in a target state, or in a state from which no target state
+// can be reached, in which case the error is a *StateError
+func (w waiter) check(record interface{}) (bool, error) {
+	state, errorMessage, ok := w.status(record)
+	if !ok || state == "" {
+		return false, nil
+	}
+	if containsString(w.states, state) {
+		return true, nil
+	}
+	if w.failure(state) || w.terminal(state) && !w.reachable(state) {
+		return true, &StateError{
+			Topic:        w.topic,
+			RecordID:     w.recordID,
+			State:        state,
+			ErrorMessage: errorMessage,
+		}
+	}
+	return false, nil
+}
+
+// reachable reports whether any of the target states can follow state
+func (w waiter) reachable(state string) bool {
+	var visited = map[string]bool{state: true}
+	var queue = []string{state}
+	for len(queue) > 0 {
+		for _, next := range w.next(queue[0]) {
+			if containsString(w.states, next) {
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+		queue = queue[1:]
+	}
+	return false
+}
+
+// waitFor subscribes to the event bus of the client before fetching the record, so no event is missed.
+// Without an event bus the record is polled, with an event bus it is polled less often in case events are missed.
+// Records which are older than the last known record are ignored, and polling continues after network errors
+// and 5xx responses. It returns the last known record, also when ctx is done.
+func (c *Client) waitFor(ctx context.Context, w waiter) (interface{}, error) {
+	if len(w.states) == 0 {
+		return nil, errors.New("acapy: no states to wait for")
+	}
+
+	var interval = c.pollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	var events <-chan Event
+	if c.eventBus != nil {
+		subscription := c.eventBus.SubscribeChan(w.topic, EventFilter{RecordID: w.recordID}, 16, DropOldest)
+		defer subscription.Unsubscribe()
+		events = subscription.C
+		interval *= eventPollFactor
+	}
+
+	client := c.WithContext(ctx)
+	record, err := w.fetch(client)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if done, err := w.check(record); done {
+			return record, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return record, ctx.Err()
+		case event := <-events:
+			if _, _, ok := w.status(event.Payload); ok && !outdated(record, event.Payload) {
+				record = event.Payload
+			}
+		case <-ticker.C:
+			next, err := w.fetch(client)
+			if IsNotFound(err) {
+				// The record existed when waiting started
+				return record, fmt.Errorf("%w: %s record %s", ErrRecordRemoved, w.topic, w.recordID)
+			}
+			if status := StatusCode(err); status >= 400 && status < 500 {
+				return record, err
+			}
+			// Network errors, timeouts and 5xx responses are retried on the next tick
+			if err == nil && !outdated(record, next) {
+				record = next
+			}
+		}
+	}
+}
+
+// outdated reports whether next was updated before record, for example because an event was delayed
+func outdated(record interface{}, next interface{}) bool {
+	updatedAt, nextUpdatedAt := NewEvent("", record).UpdatedAt, NewEvent("", next).UpdatedAt
+	return !updatedAt.IsZero() && !nextUpdatedAt.IsZero() && nextUpdatedAt.Before(updatedAt.Time)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package acapy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForFetchErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		status int
+		// drop closes the connection without a response
+		drop  bool
+		check func(err error) bool
+	}{
+		{name: "bad gateway", status: http.StatusBadGateway, check: func(err error) bool { return err == nil }},
+		{name: "internal server error", status: http.StatusInternalServerError, check: func(err error) bool { return err == nil }},
+		{name: "dropped connection", drop: true, check: func(err error) bool { return err == nil }},
+		{name: "forbidden", status: http.StatusForbidden, check: IsForbidden},
+		{name: "removed", status: http.StatusNotFound, check: func(err error) bool { return errors.Is(err, ErrRecordRemoved) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch atomic.AddInt32(&requests, 1) {
+				case 1:
+					fmt.Fprint(w, `{"connection_id":"1","state":"request"}`)
+				case 2, 3:
+					if test.drop {
+						conn, _, _ := w.(http.Hijacker).Hijack()
+						conn.Close()
+						return
+					}
+					w.WriteHeader(test.status)
+					fmt.Fprint(w, `{}`)
+				default:
+					fmt.Fprint(w, `{"connection_id":"1","state":"active"}`)
+				}
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			client := NewClient(server.URL, WithPollInterval(5*time.Millisecond))
+			connection, err := client.WaitForConnectionState(ctx, "1", ConnectionStateActive)
+			if !test.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if err == nil && connection.State != ConnectionStateActive {
+				t.Errorf("expected the active connection, got %s", connection.State)
+			}
+		})
+	}
+}
+
+func TestWaitForIgnoresOutdatedEvents(t *testing.T) {
+	var fetched = make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"connection_id":"1","state":"response","updated_at":"2021-01-01T00:00:02Z"}`)
+		fetched <- struct{}{}
+	}))
+	defer server.Close()
+
+	bus := NewEventBus()
+	client := NewClient(server.URL, WithEventBus(bus), WithPollInterval(time.Hour))
+	go func() {
+		<-fetched
+		// An event which was delayed and is older than the fetched record
+		bus.Publish(NewEvent(TopicConnections, Connection{
+			ConnectionID: "1",
+			State:        ConnectionStateActive,
+			UpdatedAt:    Timestamp{Time: time.Date(2021, 1, 1, 0, 0, 1, 0, time.UTC)},
+		}))
+		bus.Publish(NewEvent(TopicConnections, Connection{
+			ConnectionID: "1",
+			State:        ConnectionStateActive,
+			UpdatedAt:    Timestamp{Time: time.Date(2021, 1, 1, 0, 0, 3, 0, time.UTC)},
+		}))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	connection, err := client.WaitForConnectionState(ctx, "1", ConnectionStateActive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connection.UpdatedAt.Second() != 3 {
+		t.Errorf("expected the active connection updated at 00:00:03, got %s", connection.UpdatedAt)
+	}
+}
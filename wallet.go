@@ -56,7 +56,7 @@ func (c *Client) SetPublicDID(did string) (DID, error) {
 	queryParams := map[string]string{
 		"did": did,
 	}
-	err := c.post("/wallet/did/public", queryParams, nil, &r)
+	err := c.postIdempotent("/wallet/did/public", queryParams, nil, &r)
 	if err != nil {
 		return DID{}, err
 	}
@@ -73,7 +73,7 @@ func (c *Client) SetDIDEndpointInWallet(did string, endpoint string, endpointTyp
 		Endpoint:     endpoint,
 		EndpointType: endpointType,
 	}
-	return c.post("/wallet/set-did-endpoint", nil, setDIDEndpointRequest, nil)
+	return c.postIdempotent("/wallet/set-did-endpoint", nil, setDIDEndpointRequest, nil)
 }
 
 func (c *Client) GetDIDEndpointFromWallet(did string) (string, error) {
@@ -0,0 +1,185 @@
+package acapy
+
+import "encoding/json"
+
+// Webhook topics sent by ACA-py
+const (
+	TopicConnections                = "connections"
+	TopicBasicMessages              = "basicmessages"
+	TopicProblemReport              = "problem_report"
+	TopicIssueCredential            = "issue_credential"
+	TopicIssuerCredentialRevocation = "issuer_cred_rev"
+	TopicIssueCredentialV2          = "issue_credential_v2_0"
+	TopicIssueCredentialV2DIF       = "issue_credential_v2_0_dif"
+	TopicIssueCredentialV2Indy      = "issue_credential_v2_0_indy"
+	TopicIssueCredentialV2LDProof   = "issue_credential_v2_0_ld_proof"
+	TopicRevocationRegistry         = "revocation_registry"
+	TopicOutOfBandInvitation        = "oob_invitation"
+	TopicOutOfBand                  = "out_of_band"
+	TopicPresentProof               = "present_proof"
+	TopicPresentProofV2             = "present_proof_v2_0"
+	TopicPing                       = "ping"
+	TopicMediation                  = "mediation"
+	TopicKeylist                    = "keylist"
+	TopicForward                    = "forward"
+	TopicEndorseTransaction         = "endorse_transaction"
+	TopicDiscoverFeature            = "discover_feature"
+	TopicDiscoverFeatureV2          = "discover_feature_v2_0"
+	TopicRevocationNotification     = "revocation-notification"
+	TopicActionMenu                 = "actionmenu"
+	TopicQuestionAnswer             = "questionanswer"
+)
+
+type CredentialExchangeLDProof struct {
+	CredentialExchangeLDProofID string    `json:"cred_ex_ld_proof_id"`
+	CredentialExchangeID        string    `json:"cred_ex_id"`
+	CredentialIDStored          string    `json:"cred_id_stored"`
+	State                       string    `json:"state"`
+	CreatedAt                   Timestamp `json:"created_at"`
+	UpdatedAt                   Timestamp `json:"updated_at"`
+}
+
+// OutOfBandRecord is sent on the out_of_band topic by ACA-py 0.7 and later
+type OutOfBandRecord struct {
+	OutOfBandID         string              `json:"oob_id"`
+	InvitationMessageID string              `json:"invi_msg_id"`
+	Invitation          OutOfBandInvitation `json:"invitation"`
+	ConnectionID        string              `json:"connection_id"`
+	Role                OutOfBandRole       `json:"role"`
+	State               OutOfBandState      `json:"state"`
+	OurRecipientKey     string              `json:"our_recipient_key"`
+	TheirService        *Service            `json:"their_service,omitempty"`
+	AttachThreadID      string              `json:"attach_thread_id"`
+	CreatedAt           Timestamp           `json:"created_at"`
+	UpdatedAt           Timestamp           `json:"updated_at"`
+	MultiUse            bool                `json:"multi_use"`
+	Trace               bool                `json:"trace"`
+}
+
+type MediationRecord struct {
+	MediationID    string    `json:"mediation_id"`
+	ConnectionID   string    `json:"connection_id"`
+	Role           string    `json:"role"`  // server / client
+	State          string    `json:"state"` // request / granted / denied
+	MediatorTerms  []string  `json:"mediator_terms"`
+	RecipientTerms []string  `json:"recipient_terms"`
+	RoutingKeys    []string  `json:"routing_keys"`
+	Endpoint       string    `json:"endpoint"`
+	CreatedAt      Timestamp `json:"created_at"`
+	UpdatedAt      Timestamp `json:"updated_at"`
+}
+
+type KeylistEvent struct {
+	ConnectionID string          `json:"connection_id"`
+	ThreadID     string          `json:"thread_id"`
+	MediationID  string          `json:"mediation_id"`
+	Updated      []KeylistUpdate `json:"updated"`
+}
+
+type KeylistUpdate struct {
+	RecipientKey string `json:"recipient_key"`
+	Action       string `json:"action"` // add / remove
+	Result       string `json:"result"` // client_error / server_error / no_change / success
+}
+
+type ForwardEvent struct {
+	ConnectionID string `json:"connection_id"`
+	RecipientKey string `json:"recipient_key"`
+	Status       string `json:"status"` // delivered / queued_for_delivery / waiting_for_pickup
+}
+
+type EndorseTransactionRecord struct {
+	TransactionID     string            `json:"transaction_id"`
+	Type              string            `json:"_type"`
+	ConnectionID      string            `json:"connection_id"`
+	ThreadID          string            `json:"thread_id"`
+	State             string            `json:"state"`
+	Comment           string            `json:"comment"`
+	Formats           []json.RawMessage `json:"formats"`
+	MessagesAttach    []json.RawMessage `json:"messages_attach"`
+	SignatureRequest  []json.RawMessage `json:"signature_request"`
+	SignatureResponse []json.RawMessage `json:"signature_response"`
+	Timing            json.RawMessage   `json:"timing,omitempty"`
+	EndorserWriteTxn  bool              `json:"endorser_write_txn"`
+	CreatedAt         Timestamp         `json:"created_at"`
+	UpdatedAt         Timestamp         `json:"updated_at"`
+}
+
+// DiscoveryExchangeRecord is sent on the discover_feature topic
+type DiscoveryExchangeRecord struct {
+	DiscoveryExchangeID string                 `json:"discovery_exchange_id"`
+	ConnectionID        string                 `json:"connection_id"`
+	ThreadID            string                 `json:"thread_id"`
+	QueryMessage        *DiscoverFeaturesQuery `json:"query_msg,omitempty"`
+	// Disclose is nil until the disclose message of the other agent is received
+	Disclose  *DiscoverFeaturesDisclose `json:"disclose,omitempty"`
+	CreatedAt Timestamp                 `json:"created_at"`
+	UpdatedAt Timestamp                 `json:"updated_at"`
+}
+
+// DiscoveryExchangeRecordV2 is sent on the discover_feature_v2_0 topic
+type DiscoveryExchangeRecordV2 struct {
+	DiscoveryExchangeID string                   `json:"discovery_exchange_id"`
+	ConnectionID        string                   `json:"connection_id"`
+	ThreadID            string                   `json:"thread_id"`
+	QueriesMessage      *DiscoverFeaturesQueries `json:"queries_msg,omitempty"`
+	// Disclosures is nil until the disclosures message of the other agent is received
+	Disclosures *DiscoverFeaturesDisclosures `json:"disclosures,omitempty"`
+	CreatedAt   Timestamp                    `json:"created_at"`
+	UpdatedAt   Timestamp                    `json:"updated_at"`
+}
+
+type RevocationNotificationEvent struct {
+	ThreadID         string `json:"thread_id"`
+	Comment          string `json:"comment"`
+	RevocationFormat string `json:"revocation_format,omitempty"`
+	RevocationID     string `json:"revocation_id,omitempty"`
+}
+
+type ActionMenuEvent struct {
+	ConnectionID string     `json:"connection_id"`
+	Menu         ActionMenu `json:"menu"`
+}
+
+type ActionMenu struct {
+	Title        string             `json:"title"`
+	Description  string             `json:"description"`
+	ErrorMessage string             `json:"errormsg"`
+	Options      []ActionMenuOption `json:"options"`
+}
+
+type ActionMenuOption struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Disabled    bool            `json:"disabled"`
+	Form        *ActionMenuForm `json:"form,omitempty"`
+}
+
+type ActionMenuForm struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	SubmitLabel string                `json:"submit-label"`
+	Params      []ActionMenuFormParam `json:"params"`
+}
+
+type ActionMenuFormParam struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+	Input       string `json:"input"`
+	Required    bool   `json:"required"`
+}
+
+type QuestionAnswerEvent struct {
+	ConnectionID   string `json:"connection_id"`
+	ThreadID       string `json:"thread_id"`
+	State          string `json:"state"` // question received / answer received
+	QuestionText   string `json:"question_text"`
+	QuestionDetail string `json:"question_detail"`
+	ValidResponses []struct {
+		Text string `json:"text"`
+	} `json:"valid_responses"`
+	Response string `json:"response"`
+}
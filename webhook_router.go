@@ -0,0 +1,117 @@
+package acapy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// WalletIDHeader is the header in which ACA-py sends the sub-wallet of a webhook in multitenant mode
+const WalletIDHeader = "X-Wallet-Id"
+
+// WalletID returns the sub-wallet identifier of a webhook request, or an empty string for the base wallet
+func WalletID(r *http.Request) string {
+	return r.Header.Get(WalletIDHeader)
+}
+
+// ErrUnknownWallet is returned by TenantWebhookRouter for webhooks of a wallet which is not registered
+// when there is no fallback, the webhook is answered with 404 Not Found
+var ErrUnknownWallet = errors.New("acapy: webhook of unknown wallet")
+
+// TenantWebhookRouter dispatches the webhooks of an ACA-py agent in multitenant mode
+// to the EventDispatcher of the tenant the webhook belongs to, like WebhookHandlers, an EventBus or an AsyncDispatcher.
+// Tenants can be registered and unregistered at runtime.
+// Webhooks of the base wallet are sent without wallet identifier,
+// register a dispatcher for the empty wallet identifier to handle them.
+//
+// For example:
+//
+//	router := acapy.NewTenantWebhookRouter()
+//	router.Register(wallet.WalletID, acapy.WebhookHandlers{...})
+//	r.Handle("/webhooks/topic/{topic}/", router).Methods(http.MethodPost)
+type TenantWebhookRouter struct {
+	onError func(topic string, body []byte, err error)
+
+	mu       sync.RWMutex
+	tenants  map[string]EventDispatcher
+	fallback EventDispatcher
+}
+
+// TenantWebhookRouterOptions configures a TenantWebhookRouter
+type TenantWebhookRouterOptions struct {
+	// OnError is called when dispatching a webhook fails or the wallet is unknown, the error tells the wallet ID.
+	// By default the OnError of registered WebhookHandlers is called, or the error is logged.
+	OnError func(topic string, body []byte, err error)
+}
+
+func NewTenantWebhookRouter() *TenantWebhookRouter {
+	return NewTenantWebhookRouterWithOptions(TenantWebhookRouterOptions{})
+}
+
+func NewTenantWebhookRouterWithOptions(options TenantWebhookRouterOptions) *TenantWebhookRouter {
+	return &TenantWebhookRouter{
+		onError: options.OnError,
+		tenants: map[string]EventDispatcher{},
+	}
+}
+
+// Register sets the dispatcher for the tenant with walletID, replacing a previously registered dispatcher
+func (t *TenantWebhookRouter) Register(walletID string, dispatcher EventDispatcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tenants[walletID] = dispatcher
+}
+
+func (t *TenantWebhookRouter) Unregister(walletID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.tenants, walletID)
+}
+
+// SetFallback sets the dispatcher for webhooks of tenants which are not registered.
+// Without fallback, webhooks of unknown tenants are answered with 404 Not Found.
+func (t *TenantWebhookRouter) SetFallback(dispatcher EventDispatcher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fallback = dispatcher
+}
+
+func (t *TenantWebhookRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	walletID := WalletID(r)
+
+	var onError = t.onError
+	if handlers, ok := t.dispatcher(walletID).(WebhookHandlers); ok && onError == nil {
+		onError = handlers.OnError
+	}
+	webhookHandler(onError, func(topic string, body []byte) error {
+		return t.DispatchWallet(walletID, topic, body)
+	})(w, r)
+}
+
+// Dispatch dispatches an event of the base wallet
+func (t *TenantWebhookRouter) Dispatch(topic string, body []byte) error {
+	return t.DispatchWallet("", topic, body)
+}
+
+// DispatchWallet dispatches an event to the dispatcher of the tenant with walletID,
+// for example for events received by StreamEvents
+func (t *TenantWebhookRouter) DispatchWallet(walletID string, topic string, body []byte) error {
+	dispatcher := t.dispatcher(walletID)
+	if dispatcher == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownWallet, walletID)
+	}
+	if err := dispatchWallet(dispatcher, walletID, topic, body); err != nil {
+		return fmt.Errorf("wallet %q: %w", walletID, err)
+	}
+	return nil
+}
+
+func (t *TenantWebhookRouter) dispatcher(walletID string) EventDispatcher {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if dispatcher, ok := t.tenants[walletID]; ok {
+		return dispatcher
+	}
+	return t.fallback
+}
@@ -2,137 +2,381 @@ package acapy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"reflect"
 	"strings"
 )
 
 type WebhookHandlers struct {
-	ConnectionsEventHandler            func(event Connection)
-	BasicMessagesEventHandler          func(event BasicMessagesEvent)
-	ProblemReportEventHandler          func(event ProblemReportEvent)
-	CredentialExchangeEventHandler     func(event CredentialExchangeRecord)
-	CredentialExchangeV2EventHandler   func(event CredentialExchangeRecordV2)
-	CredentialExchangeDIFEventHandler  func(event CredentialExchangeDIF)
-	CredentialExchangeIndyEventHandler func(event CredentialExchangeIndy)
-	RevocationRegistryEventHandler     func(event RevocationRegistry)
-	PresentationExchangeEventHandler   func(event PresentationExchangeRecord)
-	CredentialRevocationEventHandler   func(event CredentialRevocationRecord)
-	PingEventHandler                   func(event PingEvent)
-	OutOfBandEventHandler              func(event OutOfBandEvent)
+	ConnectionsEventHandler               func(event Connection)
+	BasicMessagesEventHandler             func(event BasicMessagesEvent)
+	ProblemReportEventHandler             func(event ProblemReportEvent)
+	CredentialExchangeEventHandler        func(event CredentialExchangeRecord)
+	CredentialExchangeV2EventHandler      func(event CredentialExchangeRecordV2)
+	CredentialExchangeDIFEventHandler     func(event CredentialExchangeDIF)
+	CredentialExchangeIndyEventHandler    func(event CredentialExchangeIndy)
+	RevocationRegistryEventHandler        func(event RevocationRegistry)
+	PresentationExchangeEventHandler      func(event PresentationExchangeRecord)
+	CredentialRevocationEventHandler      func(event CredentialRevocationRecord)
+	PingEventHandler                      func(event PingEvent)
+	OutOfBandEventHandler                 func(event OutOfBandEvent)
+	OutOfBandRecordEventHandler           func(event OutOfBandRecord)
+	PresentationExchangeV2EventHandler    func(event PresentationExchangeRecordV2)
+	CredentialExchangeLDProofEventHandler func(event CredentialExchangeLDProof)
+	MediationEventHandler                 func(event MediationRecord)
+	KeylistEventHandler                   func(event KeylistEvent)
+	ForwardEventHandler                   func(event ForwardEvent)
+	EndorseTransactionEventHandler        func(event EndorseTransactionRecord)
+	DiscoverFeatureEventHandler           func(event DiscoveryExchangeRecord)
+	DiscoverFeatureV2EventHandler         func(event DiscoveryExchangeRecordV2)
+	RevocationNotificationEventHandler    func(event RevocationNotificationEvent)
+	ActionMenuEventHandler                func(event ActionMenuEvent)
+	QuestionAnswerEventHandler            func(event QuestionAnswerEvent)
+
+	// RawEventHandler is called with the raw payload for topics which are not modelled by this library.
+	// Without RawEventHandler, webhooks with unsupported topics are answered with 404 Not Found.
+	RawEventHandler func(topic string, payload json.RawMessage)
+
+	// EventHandler is called for every event after the handler of the topic, with the decoded event,
+	// for example a Connection for the connections topic, or a json.RawMessage for unsupported topics when RawEventHandler is set.
+	// Returning an error responds to ACA-py with 500 Internal Server Error, so ACA-py retries the webhook.
+	EventHandler func(topic string, event interface{}) error
+
+	// OnError is called when a webhook cannot be handled, by default the error is logged
+	OnError func(topic string, body []byte, err error)
+}
+
+// EventDispatcher dispatches the raw payload of an event sent by ACA-py on a topic,
+// it is implemented by WebhookHandlers and EventBus
+type EventDispatcher interface {
+	Dispatch(topic string, body []byte) error
+}
+
+// DecodedEventDispatcher dispatches events which have been decoded already, for example by DecodeEvent,
+// it is implemented by WebhookHandlers and EventBus
+type DecodedEventDispatcher interface {
+	DispatchEvent(event Event) error
+}
+
+// WalletEventDispatcher dispatches the raw payload of an event of a sub-wallet when ACA-py runs in multitenant mode,
+// walletID is empty for the base wallet. It is implemented by EventBus, AsyncDispatcher and TenantWebhookRouter.
+type WalletEventDispatcher interface {
+	DispatchWallet(walletID string, topic string, body []byte) error
+}
+
+// dispatchWallet dispatches an event of walletID, the wallet is dropped for dispatchers which cannot receive it
+func dispatchWallet(dispatcher EventDispatcher, walletID string, topic string, body []byte) error {
+	if dispatcher, ok := dispatcher.(WalletEventDispatcher); ok {
+		return dispatcher.DispatchWallet(walletID, topic, body)
+	}
+	return dispatcher.Dispatch(topic, body)
+}
+
+// ErrUnsupportedTopic is returned by Dispatch for topics which are not supported
+var ErrUnsupportedTopic = errors.New("unsupported webhook topic")
+
+// WebhookDecodeError is returned by Dispatch when the body of a webhook cannot be decoded
+type WebhookDecodeError struct {
+	Topic string
+	Err   error
 }
 
+func (e *WebhookDecodeError) Error() string {
+	return fmt.Sprintf("decoding %s webhook: %v", e.Topic, e.Err)
+}
+
+func (e *WebhookDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// CreateWebhooksHandler returns a handler which reads the topic from the last segment of the URL path
+// and dispatches the webhook to handlers. It responds with
+// 400 Bad Request when the body cannot be decoded,
+// 404 Not Found when the topic is not supported and there is no RawEventHandler, and
+// 500 Internal Server Error when the EventHandler returns an error.
 func CreateWebhooksHandler(handlers WebhookHandlers) func(w http.ResponseWriter, r *http.Request) {
+	return webhookHandler(handlers.OnError, handlers.Dispatch)
+}
+
+func webhookHandler(onError func(topic string, body []byte, err error), dispatch func(topic string, body []byte) error) func(w http.ResponseWriter, r *http.Request) {
+	if onError == nil {
+		onError = logWebhookError
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
-		topic := path[len(path)-1]
+		topic := webhookTopic(r)
 
 		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			onError(topic, body, err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-		switch topic {
-		case "connections":
-			if handlers.ConnectionsEventHandler != nil {
-				var connectionsEvent Connection
-				json.NewDecoder(r.Body).Decode(&connectionsEvent)
-				handlers.ConnectionsEventHandler(connectionsEvent)
-			}
-		case "basicmessages":
-			if handlers.BasicMessagesEventHandler != nil {
-				var basicMessagesEvent BasicMessagesEvent
-				json.NewDecoder(r.Body).Decode(&basicMessagesEvent)
-				handlers.BasicMessagesEventHandler(basicMessagesEvent)
-			}
-		case "problem_report":
-			if handlers.ProblemReportEventHandler != nil {
-				var problemReportEvent ProblemReportEvent
-				json.NewDecoder(r.Body).Decode(&problemReportEvent)
-				handlers.ProblemReportEventHandler(problemReportEvent)
-			}
-		case "issue_credential":
-			if handlers.CredentialExchangeEventHandler != nil {
-				var credentialExchangeEvent CredentialExchangeRecord
-				json.NewDecoder(r.Body).Decode(&credentialExchangeEvent)
-				handlers.CredentialExchangeEventHandler(credentialExchangeEvent)
-			}
-		case "issuer_cred_rev":
-			if handlers.CredentialRevocationEventHandler != nil {
-				var credentialRevocationEvent CredentialRevocationRecord
-				json.NewDecoder(r.Body).Decode(&credentialRevocationEvent)
-				handlers.CredentialRevocationEventHandler(credentialRevocationEvent)
-			}
-		case "issue_credential_v2_0":
-			if handlers.CredentialExchangeV2EventHandler != nil {
-				var credentialExchangeV2Event CredentialExchangeRecordV2
-				json.NewDecoder(r.Body).Decode(&credentialExchangeV2Event)
-				handlers.CredentialExchangeV2EventHandler(credentialExchangeV2Event)
-			}
-		case "issue_credential_v2_0_dif":
-			body, _ := ioutil.ReadAll(r.Body)
-			fmt.Println(string(body))
-			if handlers.CredentialExchangeDIFEventHandler != nil {
-				var credentialExchangeDIFEvent CredentialExchangeDIF
-				if err := json.Unmarshal(body, &credentialExchangeDIFEvent); err != nil {
-					log.Fatal(err)
-				}
-				handlers.CredentialExchangeDIFEventHandler(credentialExchangeDIFEvent)
-			}
-		case "issue_credential_v2_0_indy":
-			if handlers.CredentialExchangeIndyEventHandler != nil {
-				var credentialExchangeIndyEvent CredentialExchangeIndy
-				json.NewDecoder(r.Body).Decode(&credentialExchangeIndyEvent)
-				handlers.CredentialExchangeIndyEventHandler(credentialExchangeIndyEvent)
-			}
-		case "revocation_registry":
-			if handlers.RevocationRegistryEventHandler != nil {
-				var revocationRegistryEvent RevocationRegistry
-				json.NewDecoder(r.Body).Decode(&revocationRegistryEvent)
-				handlers.RevocationRegistryEventHandler(revocationRegistryEvent)
-			}
-		case "oob_invitation":
-			if handlers.OutOfBandEventHandler != nil {
-				var outOfBandEvent OutOfBandEvent
-				json.NewDecoder(r.Body).Decode(&outOfBandEvent)
-				handlers.OutOfBandEventHandler(outOfBandEvent)
-			}
-		case "present_proof":
-			if handlers.PresentationExchangeEventHandler != nil {
-				var presentationExchangeEvent PresentationExchangeRecord
-				json.NewDecoder(r.Body).Decode(&presentationExchangeEvent)
-				handlers.PresentationExchangeEventHandler(presentationExchangeEvent)
-			}
-		case "ping":
-			if handlers.PingEventHandler != nil {
-				var pingEvent PingEvent
-				json.NewDecoder(r.Body).Decode(&pingEvent)
-				handlers.PingEventHandler(pingEvent)
-			}
-		default:
-			log.Printf("Webhook topic not supported: %q\n", topic)
-			w.WriteHeader(404)
+		if err := dispatch(topic, body); err != nil {
+			onError(topic, body, err)
+			w.WriteHeader(webhookErrorStatus(err))
 			return
 		}
-		w.WriteHeader(200)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func webhookTopic(r *http.Request) string {
+	path := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	return path[len(path)-1]
+}
+
+func webhookErrorStatus(err error) int {
+	var decodeError *WebhookDecodeError
+	switch {
+	case errors.As(err, &decodeError):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnsupportedTopic), errors.Is(err, ErrUnknownWallet):
+		return http.StatusNotFound
+	case errors.Is(err, ErrDispatcherClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func logWebhookError(topic string, body []byte, err error) {
+	log.Printf("Webhook %q failed: %v\n", topic, err)
+}
+
+// Dispatch decodes the body of a webhook and calls the handler of the topic
+func (handlers WebhookHandlers) Dispatch(topic string, body []byte) error {
+	return handlers.dispatch(topic, body, nil)
+}
+
+// DispatchEvent calls the handler of the topic of an event which has been decoded already, for example by DecodeEvent
+func (handlers WebhookHandlers) DispatchEvent(event Event) error {
+	if payload, ok := event.Payload.(json.RawMessage); ok {
+		return handlers.dispatch(event.Topic, payload, nil)
+	}
+	return handlers.dispatch(event.Topic, nil, event.Payload)
+}
+
+// dispatch calls the handlers of the topic with payload, or with the decoded body when payload is nil.
+// The body is only decoded when a handler of the topic or EventHandler is set, so webhooks
+// on topics which are not handled are answered with 200 OK even when they cannot be decoded.
+func (handlers WebhookHandlers) dispatch(topic string, body []byte, payload interface{}) error {
+	target, handle := handlers.topicHandler(topic)
+	if target == nil {
+		if handlers.RawEventHandler == nil {
+			return fmt.Errorf("%w: %q", ErrUnsupportedTopic, topic)
+		}
+		handlers.RawEventHandler(topic, body)
+		if handlers.EventHandler != nil {
+			return handlers.EventHandler(topic, json.RawMessage(body))
+		}
+		return nil
+	}
+	if handle == nil && handlers.EventHandler == nil {
+		return nil
+	}
+
+	if payload != nil {
+		value, event := reflect.ValueOf(payload), reflect.ValueOf(target).Elem()
+		if value.Type() != event.Type() {
+			return &WebhookDecodeError{Topic: topic, Err: fmt.Errorf("payload is a %T, not a %s", payload, event.Type())}
+		}
+		event.Set(value)
+	} else if err := json.Unmarshal(body, target); err != nil {
+		return &WebhookDecodeError{Topic: topic, Err: err}
+	}
+
+	if handle != nil {
+		handle()
+	}
+	if handlers.EventHandler != nil {
+		return handlers.EventHandler(topic, reflect.ValueOf(target).Elem().Interface())
+	}
+	return nil
+}
+
+// handles reports whether any handler is called for the events on topic
+func (handlers WebhookHandlers) handles(topic string) bool {
+	target, handle := handlers.topicHandler(topic)
+	if target == nil {
+		return handlers.RawEventHandler != nil
+	}
+	return handle != nil || handlers.EventHandler != nil
+}
+
+// topicHandler returns a pointer to decode the payload of topic into, which is nil for unsupported topics,
+// and a function which calls the handler of the topic with the decoded payload, which is nil when the handler is not set
+func (handlers WebhookHandlers) topicHandler(topic string) (interface{}, func()) {
+	switch topic {
+	case TopicConnections:
+		var event Connection
+		if handlers.ConnectionsEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.ConnectionsEventHandler(event) }
+	case TopicBasicMessages:
+		var event BasicMessagesEvent
+		if handlers.BasicMessagesEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.BasicMessagesEventHandler(event) }
+	case TopicProblemReport:
+		var event ProblemReportEvent
+		if handlers.ProblemReportEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.ProblemReportEventHandler(event) }
+	case TopicIssueCredential:
+		var event CredentialExchangeRecord
+		if handlers.CredentialExchangeEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialExchangeEventHandler(event) }
+	case TopicIssuerCredentialRevocation:
+		var event CredentialRevocationRecord
+		if handlers.CredentialRevocationEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialRevocationEventHandler(event) }
+	case TopicIssueCredentialV2:
+		var event CredentialExchangeRecordV2
+		if handlers.CredentialExchangeV2EventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialExchangeV2EventHandler(event) }
+	case TopicIssueCredentialV2DIF:
+		var event CredentialExchangeDIF
+		if handlers.CredentialExchangeDIFEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialExchangeDIFEventHandler(event) }
+	case TopicIssueCredentialV2Indy:
+		var event CredentialExchangeIndy
+		if handlers.CredentialExchangeIndyEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialExchangeIndyEventHandler(event) }
+	case TopicRevocationRegistry:
+		var event RevocationRegistry
+		if handlers.RevocationRegistryEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.RevocationRegistryEventHandler(event) }
+	case TopicOutOfBandInvitation:
+		var event OutOfBandEvent
+		if handlers.OutOfBandEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.OutOfBandEventHandler(event) }
+	case TopicPresentProof:
+		var event PresentationExchangeRecord
+		if handlers.PresentationExchangeEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.PresentationExchangeEventHandler(event) }
+	case TopicPing:
+		var event PingEvent
+		if handlers.PingEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.PingEventHandler(event) }
+	case TopicIssueCredentialV2LDProof:
+		var event CredentialExchangeLDProof
+		if handlers.CredentialExchangeLDProofEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.CredentialExchangeLDProofEventHandler(event) }
+	case TopicOutOfBand:
+		var event OutOfBandRecord
+		if handlers.OutOfBandRecordEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.OutOfBandRecordEventHandler(event) }
+	case TopicPresentProofV2:
+		var event PresentationExchangeRecordV2
+		if handlers.PresentationExchangeV2EventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.PresentationExchangeV2EventHandler(event) }
+	case TopicMediation:
+		var event MediationRecord
+		if handlers.MediationEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.MediationEventHandler(event) }
+	case TopicKeylist:
+		var event KeylistEvent
+		if handlers.KeylistEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.KeylistEventHandler(event) }
+	case TopicForward:
+		var event ForwardEvent
+		if handlers.ForwardEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.ForwardEventHandler(event) }
+	case TopicEndorseTransaction:
+		var event EndorseTransactionRecord
+		if handlers.EndorseTransactionEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.EndorseTransactionEventHandler(event) }
+	case TopicDiscoverFeature:
+		var event DiscoveryExchangeRecord
+		if handlers.DiscoverFeatureEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.DiscoverFeatureEventHandler(event) }
+	case TopicDiscoverFeatureV2:
+		var event DiscoveryExchangeRecordV2
+		if handlers.DiscoverFeatureV2EventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.DiscoverFeatureV2EventHandler(event) }
+	case TopicRevocationNotification:
+		var event RevocationNotificationEvent
+		if handlers.RevocationNotificationEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.RevocationNotificationEventHandler(event) }
+	case TopicActionMenu:
+		var event ActionMenuEvent
+		if handlers.ActionMenuEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.ActionMenuEventHandler(event) }
+	case TopicQuestionAnswer:
+		var event QuestionAnswerEvent
+		if handlers.QuestionAnswerEventHandler == nil {
+			return &event, nil
+		}
+		return &event, func() { handlers.QuestionAnswerEventHandler(event) }
 	}
+	return nil, nil
 }
 
 type PingEvent struct {
-	Comment      string `json:"comment"`
-	ConnectionID string `json:"connection_id"`
-	Responded    bool   `json:"responded"`
-	State        string `json:"state"`
-	ThreadID     string `json:"thread_id"`
+	Comment      string    `json:"comment"`
+	ConnectionID string    `json:"connection_id"`
+	Responded    bool      `json:"responded"`
+	State        PingState `json:"state"`
+	ThreadID     string    `json:"thread_id"`
 }
 
 type OutOfBandEvent struct {
 	InvitationID        string              `json:"invitation_id"`
 	InvitationMessageID string              `json:"invi_msg_id"`
 	Invitation          OutOfBandInvitation `json:"invitation"`
-	State               string              `json:"state"`
+	State               OutOfBandState      `json:"state"`
 	InvitationURL       string              `json:"invitation_url"`
-	UpdatedAt           string              `json:"updated_at"`
-	CreatedAt           string              `json:"created_at"`
+	UpdatedAt           Timestamp           `json:"updated_at"`
+	CreatedAt           Timestamp           `json:"created_at"`
 	AutoAccept          bool                `json:"auto_accept"`
 	MultiUse            bool                `json:"multi_use"`
 	Trace               bool                `json:"trace"`
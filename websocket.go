@@ -0,0 +1,397 @@
+package acapy
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A minimal RFC 6455 WebSocket client, just enough to read the events of the ACA-py admin WebSocket
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessageSize = 32 << 20
+)
+
+var errWebSocketClosed = errors.New("websocket closed")
+
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex // guards writes
+}
+
+// dialWebSocket connects to wsURL (ws:// or wss://) and performs the opening handshake.
+// The proxy of httpTransport is used, and the handshakes are aborted when ctx is done.
+func dialWebSocket(ctx context.Context, wsURL string, header http.Header, httpTransport *http.Transport, tlsConfig *tls.Config) (*wsConn, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return nil, err
+	}
+	var secure = u.Scheme == "wss"
+	var address = hostPort(u, secure)
+
+	proxyURL, err := webSocketProxy(u, httpTransport)
+	if err != nil {
+		return nil, err
+	}
+	var dialAddress = address
+	if proxyURL != nil {
+		dialAddress = hostPort(proxyURL, proxyURL.Scheme == "https")
+	}
+
+	var dial = (&net.Dialer{Timeout: 30 * time.Second}).DialContext
+	if httpTransport != nil && httpTransport.DialContext != nil {
+		dial = httpTransport.DialContext
+	}
+	conn, err := dial(ctx, "tcp", dialAddress)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	// Abort the proxy, TLS and WebSocket handshakes when ctx is done, by expiring the deadline of the connection
+	var stop = make(chan struct{})
+	var stopped = make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+	var fail = func(err error) (*wsConn, error) {
+		close(stop)
+		<-stopped
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if proxyURL != nil {
+		if proxyURL.Scheme == "https" {
+			tlsConn := tls.Client(conn, webSocketTLSConfig(proxyURL, httpTransport, tlsConfig))
+			if err := tlsConn.Handshake(); err != nil {
+				return fail(err)
+			}
+			conn = tlsConn
+		}
+		var proxyHeader http.Header
+		if httpTransport != nil {
+			proxyHeader = httpTransport.ProxyConnectHeader
+		}
+		if err := connectProxy(conn, proxyURL, address, proxyHeader); err != nil {
+			return fail(err)
+		}
+	}
+
+	if secure {
+		tlsConn := tls.Client(conn, webSocketTLSConfig(u, httpTransport, tlsConfig))
+		if err := tlsConn.Handshake(); err != nil {
+			return fail(err)
+		}
+		conn = tlsConn
+	}
+
+	ws, err := handshakeWebSocket(conn, u, header)
+	if err != nil {
+		return fail(err)
+	}
+
+	close(stop)
+	<-stopped
+	if ctx.Err() != nil {
+		conn.Close()
+		return nil, ctx.Err()
+	}
+	conn.SetDeadline(time.Time{})
+	return ws, nil
+}
+
+// hostPort returns the host and port of u, with the default port of the scheme when u has no port
+func hostPort(u *url.URL, secure bool) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if secure {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+func webSocketTLSConfig(u *url.URL, httpTransport *http.Transport, tlsConfig *tls.Config) *tls.Config {
+	var config *tls.Config
+	switch {
+	case tlsConfig != nil:
+		config = tlsConfig.Clone()
+	case httpTransport != nil && httpTransport.TLSClientConfig != nil:
+		config = httpTransport.TLSClientConfig.Clone()
+	default:
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if config.ServerName == "" {
+		config.ServerName = u.Hostname()
+	}
+	config.NextProtos = []string{"http/1.1"}
+	return config
+}
+
+// webSocketProxy returns the proxy of httpTransport for the http(s) equivalent of the WebSocket URL,
+// or nil when the connection is direct
+func webSocketProxy(u *url.URL, httpTransport *http.Transport) (*url.URL, error) {
+	if httpTransport == nil || httpTransport.Proxy == nil {
+		return nil, nil
+	}
+	httpURL := *u
+	httpURL.Scheme = strings.Replace(u.Scheme, "ws", "http", 1)
+	r, err := http.NewRequest(http.MethodGet, httpURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpTransport.Proxy(r)
+}
+
+// connectProxy opens a tunnel to address through the HTTP proxy conn is connected to
+func connectProxy(conn net.Conn, proxyURL *url.URL, address string, header http.Header) error {
+	r := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	for k, values := range header {
+		for _, value := range values {
+			r.Header.Add(k, value)
+		}
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		r.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := r.Write(conn); err != nil {
+		return err
+	}
+
+	// Read the response byte by byte, so no bytes of the tunnelled connection are buffered
+	response, err := http.ReadResponse(bufio.NewReaderSize(byteReader{conn}, 16), r)
+	if err != nil {
+		return err
+	}
+	// The body is not read, the connection becomes the tunnel
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("websocket: proxy %s: %s", proxyURL.Host, response.Status)
+	}
+	return nil
+}
+
+// byteReader reads at most one byte at a time
+type byteReader struct {
+	io.Reader
+}
+
+func (r byteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return r.Reader.Read(p)
+}
+
+func handshakeWebSocket(conn net.Conn, u *url.URL, header http.Header) (*wsConn, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	httpURL := *u
+	httpURL.Scheme = strings.Replace(u.Scheme, "ws", "http", 1)
+	r, err := http.NewRequest(http.MethodGet, httpURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		for _, value := range values {
+			r.Header.Add(k, value)
+		}
+	}
+	r.Header.Del("Content-Type")
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Key", key)
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	if err := r.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, r)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+		response.Body.Close()
+		return nil, newAPIError(http.MethodGet, u.Path, &AdminResponse{
+			StatusCode: response.StatusCode,
+			Header:     response.Header,
+			Body:       body,
+		})
+	}
+
+	accept := sha1.Sum([]byte(key + wsGUID))
+	if response.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(accept[:]) {
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Accept header")
+	}
+	return &wsConn{conn: conn, reader: reader}, nil
+}
+
+// ReadMessage returns the next text or binary message, answering pings and reassembling fragments
+func (ws *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+		case wsOpPong:
+		case wsOpClose:
+			_ = ws.writeFrame(wsOpClose, payload)
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if len(message) > wsMaxMessageSize {
+				return nil, errors.New("websocket: message too large")
+			}
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+	}
+}
+
+func (ws *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single masked frame, as required for frames sent by a client
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+func (ws *wsConn) Ping() error {
+	return ws.writeFrame(wsOpPing, nil)
+}
+
+func (ws *wsConn) SetReadDeadline(deadline time.Time) error {
+	return ws.conn.SetReadDeadline(deadline)
+}
+
+// Close sends a normal closure frame and closes the connection
+func (ws *wsConn) Close() error {
+	_ = ws.writeFrame(wsOpClose, []byte{0x03, 0xE8})
+	return ws.conn.Close()
+}
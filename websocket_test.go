@@ -0,0 +1,544 @@
+package acapy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wsTestConn is the server side of a WebSocket connection
+type wsTestConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func acceptWebSocket(t *testing.T, w http.ResponseWriter, r *http.Request) *wsTestConn {
+	t.Helper()
+	if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Errorf("unexpected upgrade request headers: %v", r.Header)
+	}
+	accept := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsGUID))
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+	rw.Flush()
+	return &wsTestConn{conn: conn, rw: rw}
+}
+
+// writeFrame writes an unmasked frame, as sent by a server
+func (c *wsTestConn) writeFrame(fin bool, opcode byte, payload []byte) error {
+	var first = opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+	frame = append(frame, payload...)
+	if _, err := c.rw.Write(frame); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+func (c *wsTestConn) writeText(message string) error {
+	return c.writeFrame(true, wsOpText, []byte(message))
+}
+
+// readFrame reads a frame sent by the client, which must be masked
+func (c *wsTestConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return 0, nil, err
+	}
+	if header[1]&0x80 == 0 {
+		return 0, nil, errors.New("client frame is not masked")
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.rw, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.rw, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return header[0] & 0x0F, payload, nil
+}
+
+func newWebSocketServer(t *testing.T, handle func(r *http.Request, c *wsTestConn)) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := acceptWebSocket(t, w, r)
+		defer c.conn.Close()
+		handle(r, c)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func webSocketURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func dialTestWebSocket(t *testing.T, wsURL string, httpTransport *http.Transport) *wsConn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ws, err := dialWebSocket(ctx, wsURL, http.Header{}, httpTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.conn.Close() })
+	return ws
+}
+
+func TestWebSocketFraming(t *testing.T) {
+	var medium = strings.Repeat("m", 300)
+	var large = strings.Repeat("l", 70000)
+	var received = make(chan []byte, 1)
+
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		c.writeFrame(false, wsOpText, []byte("hello "))
+		c.writeFrame(false, wsOpContinuation, []byte("fragmented "))
+		c.writeFrame(true, wsOpContinuation, []byte("world"))
+		c.writeText(medium)
+		c.writeFrame(true, wsOpBinary, []byte(large))
+
+		opcode, payload, err := c.readFrame()
+		if err != nil || opcode != wsOpText {
+			t.Errorf("reading client frame: opcode %d, err %v", opcode, err)
+		}
+		received <- payload
+	})
+
+	ws := dialTestWebSocket(t, webSocketURL(server), nil)
+	for _, expected := range []string{"hello fragmented world", medium, large} {
+		message, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(message) != expected {
+			t.Errorf("expected message of %d bytes, got %d bytes", len(expected), len(message))
+		}
+	}
+
+	var clientMessage = bytes.Repeat([]byte("c"), 200)
+	if err := ws.writeFrame(wsOpText, clientMessage); err != nil {
+		t.Fatal(err)
+	}
+	if payload := <-received; !bytes.Equal(payload, clientMessage) {
+		t.Errorf("server received %q", payload)
+	}
+}
+
+func TestWebSocketPingPong(t *testing.T) {
+	var frames = make(chan string, 2)
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		c.writeFrame(true, wsOpPing, []byte("are you there"))
+		c.writeText("after ping")
+		for i := 0; i < 2; i++ {
+			opcode, payload, err := c.readFrame()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			frames <- fmt.Sprintf("%d:%s", opcode, payload)
+		}
+	})
+
+	ws := dialTestWebSocket(t, webSocketURL(server), nil)
+	message, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(message) != "after ping" {
+		t.Errorf("expected the message after the ping, got %q", message)
+	}
+	if err := ws.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pong answers the ping of the server with the same payload, then the client sends its own ping
+	if frame := <-frames; frame != "10:are you there" {
+		t.Errorf("expected pong with payload, got %q", frame)
+	}
+	if frame := <-frames; frame != "9:" {
+		t.Errorf("expected ping, got %q", frame)
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	var echoed = make(chan []byte, 1)
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		c.writeFrame(true, wsOpClose, []byte{0x03, 0xE8})
+		opcode, payload, err := c.readFrame()
+		if err != nil || opcode != wsOpClose {
+			t.Errorf("expected close frame, got opcode %d, err %v", opcode, err)
+		}
+		echoed <- payload
+	})
+
+	ws := dialTestWebSocket(t, webSocketURL(server), nil)
+	if _, err := ws.ReadMessage(); err != errWebSocketClosed {
+		t.Errorf("expected errWebSocketClosed, got %v", err)
+	}
+	if payload := <-echoed; !bytes.Equal(payload, []byte{0x03, 0xE8}) {
+		t.Errorf("expected the close code to be echoed, got %v", payload)
+	}
+}
+
+func TestWebSocketRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := dialWebSocket(context.Background(), webSocketURL(server), http.Header{}, nil, nil)
+	if !IsUnauthorized(err) {
+		t.Errorf("expected 401 Unauthorized APIError, got %v", err)
+	}
+}
+
+func TestWebSocketDialCancelled(t *testing.T) {
+	// A server which accepts connections but never answers the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	var start = time.Now()
+	_, err = dialWebSocket(ctx, "ws://"+listener.Addr().String()+"/ws", http.Header{}, nil, nil)
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("dial was not cancelled, it took %s", elapsed)
+	}
+}
+
+func TestWebSocketProxy(t *testing.T) {
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		c.writeText("through the proxy")
+		c.readFrame()
+	})
+
+	var connects int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			t.Errorf("expected CONNECT, got %s", r.Method)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+		if r.Header.Get("Proxy-Authorization") != "Basic "+credentials {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(&connects, 1)
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 Connection established\r\n\r\n")
+		rw.Flush()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			io.Copy(target, rw)
+		}()
+		io.Copy(conn, target)
+		conn.Close()
+		wg.Wait()
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	ws := dialTestWebSocket(t, webSocketURL(server), &http.Transport{Proxy: http.ProxyURL(proxyURL)})
+
+	message, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(message) != "through the proxy" {
+		t.Errorf("unexpected message %q", message)
+	}
+	if atomic.LoadInt32(&connects) != 1 {
+		t.Errorf("expected one CONNECT through the proxy, got %d", connects)
+	}
+}
+
+type dispatchFunc func(topic string, body []byte) error
+
+func (f dispatchFunc) Dispatch(topic string, body []byte) error {
+	return f(topic, body)
+}
+
+func TestStreamEventsReconnects(t *testing.T) {
+	var connections int32
+	var apiKeys = make(chan string, 10)
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		apiKeys <- r.Header.Get("X-API-KEY")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			c.writeText(`{"topic":"settings","payload":{"authenticated":true}}`)
+			c.writeText(`{"topic":"ping","payload":{"connection_id":"first","state":"received"}}`)
+			// Lose the connection without a close frame
+		default:
+			c.writeText(`{"topic":"ping","payload":{"connection_id":"second","state":"received"}}`)
+			// Keep the connection open until the client closes it
+			for {
+				if _, _, err := c.readFrame(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	var events = make(chan string, 10)
+	dispatcher := dispatchFunc(func(topic string, body []byte) error {
+		events <- topic + " " + string(body)
+		return nil
+	})
+
+	var disconnects int32
+	var connects int32
+	client := NewClient(server.URL, WithAPIKey("secret"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var result = make(chan error, 1)
+	go func() {
+		result <- client.StreamEvents(ctx, dispatcher, EventStreamOptions{
+			InitialBackoff: 20 * time.Millisecond,
+			MaxBackoff:     40 * time.Millisecond,
+			PingInterval:   time.Second,
+			OnConnect:      func() { atomic.AddInt32(&connects, 1) },
+			OnDisconnect:   func(err error) { atomic.AddInt32(&disconnects, 1) },
+		})
+	}()
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case event := <-events:
+			if !strings.HasPrefix(event, "ping ") || !strings.Contains(event, `"connection_id":"`+expected+`"`) {
+				t.Errorf("expected ping event of %s, got %s", expected, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event of the %s connection", expected)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamEvents did not return after ctx was cancelled")
+	}
+
+	if atomic.LoadInt32(&connects) != 2 || atomic.LoadInt32(&disconnects) != 1 {
+		t.Errorf("expected 2 connects and 1 disconnect, got %d and %d", connects, disconnects)
+	}
+	close(apiKeys)
+	for apiKey := range apiKeys {
+		if apiKey != "secret" {
+			t.Errorf("expected the API key on every connection, got %q", apiKey)
+		}
+	}
+}
+
+func TestStreamEventsRejected(t *testing.T) {
+	var tests = []struct {
+		name   string
+		status int
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden},
+		{name: "not found", status: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := NewClient(server.URL).StreamEvents(ctx, NewEventBus(), EventStreamOptions{InitialBackoff: time.Millisecond})
+			if StatusCode(err) != test.status {
+				t.Errorf("expected APIError with status %d, got %v", test.status, err)
+			}
+			if atomic.LoadInt32(&attempts) != 1 {
+				t.Errorf("expected a single attempt, got %d", attempts)
+			}
+		})
+	}
+}
+
+func TestStreamEventsRetriesServerErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		c := acceptWebSocket(t, w, r)
+		defer c.conn.Close()
+		c.writeText(`{"topic":"ping","payload":{"connection_id":"1","state":"received"}}`)
+		for {
+			if _, _, err := c.readFrame(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	var events = make(chan string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var result = make(chan error, 1)
+	go func() {
+		result <- NewClient(server.URL).StreamEvents(ctx, dispatchFunc(func(topic string, body []byte) error {
+			events <- topic
+			return nil
+		}), EventStreamOptions{InitialBackoff: 10 * time.Millisecond})
+	}()
+
+	select {
+	case <-events:
+	case err := <-result:
+		t.Fatalf("StreamEvents returned %v instead of retrying", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event after the server error")
+	}
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestStreamEventsWalletID(t *testing.T) {
+	server := newWebSocketServer(t, func(r *http.Request, c *wsTestConn) {
+		c.writeText(`{"topic":"ping","wallet_id":"acme","payload":{"connection_id":"1","state":"received"}}`)
+		c.writeText(`{"topic":"ping","wallet_id":"unknown","payload":{"connection_id":"2","state":"received"}}`)
+		c.writeText(`{"topic":"ping","payload":{"connection_id":"3","state":"received"}}`)
+		for {
+			if _, _, err := c.readFrame(); err != nil {
+				return
+			}
+		}
+	})
+
+	acme := NewEventBus()
+	acmeEvents := acme.SubscribeChan(TopicPing, EventFilter{}, 10, Block)
+	base := NewEventBus()
+	baseEvents := base.SubscribeChan(TopicPing, EventFilter{}, 10, Block)
+	router := NewTenantWebhookRouter()
+	router.Register("acme", acme)
+	router.Register("", base)
+
+	var errs = make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewClient(server.URL).StreamEvents(ctx, router, EventStreamOptions{
+		OnError: func(topic string, body []byte, err error) { errs <- err },
+	})
+
+	for _, expected := range []struct {
+		subscription *Subscription
+		connectionID string
+		walletID     string
+	}{
+		{subscription: acmeEvents, connectionID: "1", walletID: "acme"},
+		{subscription: baseEvents, connectionID: "3", walletID: ""},
+	} {
+		select {
+		case event := <-expected.subscription.C:
+			if event.ConnectionID != expected.connectionID || event.WalletID != expected.walletID {
+				t.Errorf("expected event of connection %s and wallet %q, got %s and %q", expected.connectionID, expected.walletID, event.ConnectionID, event.WalletID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event of connection %s", expected.connectionID)
+		}
+	}
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrUnknownWallet) {
+			t.Errorf("expected ErrUnknownWallet, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event of the unknown wallet was not reported")
+	}
+}